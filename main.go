@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"qsyorm/qsydialect"
 	"qsyorm/qsyengine"
 	"qsyorm/qsylog"
 
@@ -19,20 +20,20 @@ import (
 
 // User 用户模型
 type User struct {
-	ID       int64  `qsy:"name:ID;primarykey;autoincrement"`
-	Username string `qsy:"name:Username;unique"`
-	Password string `qsy:"name:Password"`
-	Age      int    `qsy:"name:Age;index"`
-	Created  string `qsy:"name:Created"`
+	ID       int64     `qsy:"name:ID;primarykey;autoincrement"`
+	Username string    `qsy:"name:Username;unique;not null"`
+	Password string    `qsy:"name:Password;not null"`
+	Age      int       `qsy:"name:Age;index"`
+	Created  time.Time `qsy:"name:Created;autoCreateTime"`
 }
 
 // Article 文章模型
 type Article struct {
-	ID        int64  `qsy:"name:ID;primarykey;autoincrement"`
-	Title     string `qsy:"name:Title;index"`
-	Content   string `qsy:"name:Content"`
-	UserID    int64  `qsy:"name:UserID;index"`
-	CreatedAt string `qsy:"name:CreatedAt"`
+	ID        int64     `qsy:"name:ID;primarykey;autoincrement"`
+	Title     string    `qsy:"name:Title;index;not null"`
+	Content   string    `qsy:"name:Content"`
+	UserID    int64     `qsy:"name:UserID;index"`
+	CreatedAt time.Time `qsy:"name:CreatedAt"`
 }
 
 var (
@@ -101,7 +102,6 @@ func insertTestData() error {
 			Username: fmt.Sprintf("user%d", i),
 			Password: fmt.Sprintf("pass%d", i),
 			Age:      20 + i,
-			Created:  time.Now().Format("2006-01-02 15:04:05"),
 		}
 
 		// 先设置Model以提供Schema
@@ -115,10 +115,9 @@ func insertTestData() error {
 	// 插入文章数据
 	for i := 1; i <= 20; i++ {
 		article := &Article{
-			Title:     fmt.Sprintf("Article Title %d", i),
-			Content:   fmt.Sprintf("This is the content of article %d", i),
-			UserID:    int64((i % 10) + 1),
-			CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+			Title:   fmt.Sprintf("Article Title %d", i),
+			Content: fmt.Sprintf("This is the content of article %d", i),
+			UserID:  int64((i % 10) + 1),
 		}
 
 		// 先设置Model以提供Schema
@@ -227,34 +226,9 @@ func getTableData(tableName string, limit, offset int) ([]map[string]interface{}
 
 	// 查询表数据
 	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d OFFSET %d;", tableName, limit, offset)
-	rows, err := session.Raw(query).QueryRows()
-	if err != nil {
-		return nil, nil, err
-	}
-	defer rows.Close()
-
 	var data []map[string]interface{}
-	for rows.Next() {
-		// 为每一行创建一个动态的值数组
-		values := make([]interface{}, len(columnNames))
-		valuePtrs := make([]interface{}, len(columnNames))
-
-		for i := range columnNames {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, nil, err
-		}
-
-		// 将扫描值转换为map
-		rowData := make(map[string]interface{})
-		for i, col := range columnNames {
-			val := values[i]
-			rowData[col] = val
-		}
-
-		data = append(data, rowData)
+	if err := session.Raw(query).Scan(&data); err != nil {
+		return nil, nil, err
 	}
 
 	return data, columnNames, nil
@@ -268,51 +242,10 @@ func executeQuerySQL(query string) ([]map[string]interface{}, error) {
 	db := dbEngine.NewSession()
 	defer db.Close()
 
-	// 执行查询
-	rows, err := db.Raw(query).QueryRows()
-	if err != nil {
+	// 执行查询并扫描为 map 切片
+	if err := db.Raw(query).Scan(&results); err != nil {
 		return nil, fmt.Errorf("执行查询失败: %v", err)
 	}
-	defer rows.Close()
-
-	// 获取列名
-	columns, err := rows.Columns()
-	if err != nil {
-		return nil, fmt.Errorf("获取列名失败: %v", err)
-	}
-
-	// 准备接收查询结果的变量
-	values := make([]interface{}, len(columns))
-	scanArgs := make([]interface{}, len(columns))
-	for i := range values {
-		scanArgs[i] = &values[i]
-	}
-
-	// 遍历结果集
-	for rows.Next() {
-		err = rows.Scan(scanArgs...)
-		if err != nil {
-			return nil, fmt.Errorf("扫描行数据失败: %v", err)
-		}
-
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-
-			b, ok := val.([]byte)
-			if ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
-		}
-
-		results = append(results, row)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("读取结果集时发生错误: %v", err)
-	}
 
 	return results, nil
 }
@@ -351,32 +284,19 @@ func createTable(tableName string, columns []map[string]string) error {
 
 		log.Printf("处理列: %s, 类型: %s", columnName, columnType)
 
-		// 构建列定义
-		var constraints []string
-
-		if col["primaryKey"] == "true" {
-			constraints = append(constraints, "PRIMARY KEY")
-			log.Printf("列 %s 设为主键", columnName)
-		}
-
-		if col["autoIncrement"] == "true" {
-			constraints = append(constraints, "AUTOINCREMENT")
-			log.Printf("列 %s 设为自增", columnName)
-		}
-
-		if col["notNull"] == "true" {
-			constraints = append(constraints, "NOT NULL")
-			log.Printf("列 %s 设为非空", columnName)
-		}
-
-		if col["unique"] == "true" {
-			constraints = append(constraints, "UNIQUE")
-			log.Printf("列 %s 设为唯一", columnName)
+		// 构建列定义，约束由方言渲染
+		column := qsydialect.Column{
+			Name:          columnName,
+			Type:          columnType,
+			PrimaryKey:    col["primaryKey"] == "true",
+			AutoIncrement: col["autoIncrement"] == "true",
+			NotNull:       col["notNull"] == "true",
+			Unique:        col["unique"] == "true",
 		}
 
-		definition := fmt.Sprintf("%s %s %s", columnName, columnType, strings.Join(constraints, " "))
-		columnDefs = append(columnDefs, strings.TrimSpace(definition))
-		log.Printf("最终列定义: %s", strings.TrimSpace(definition))
+		definition := dbEngine.Dialect().ColumnDefinition(column)
+		columnDefs = append(columnDefs, definition)
+		log.Printf("最终列定义: %s", definition)
 	}
 
 	// 创建表的SQL
@@ -0,0 +1,524 @@
+package qsyclause
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Dialect is the part of a SQL dialect a Writer needs to render expressions
+type Dialect interface {
+	// BindVar returns the placeholder for the n-th (1-based) bind var
+	BindVar(n int) string
+	// Quote quotes a column or table identifier
+	Quote(name string) string
+}
+
+// Expression is a node of a SQL expression tree that renders itself into a Writer
+type Expression interface {
+	Build(w *Writer)
+}
+
+// Writer collects the SQL text and bind vars produced by expressions.
+// A nil Dialect renders "?" placeholders and leaves identifiers unquoted.
+type Writer struct {
+	strings.Builder
+	Vars    []interface{}
+	Dialect Dialect
+}
+
+// NewWriter creates a Writer for the given dialect
+func NewWriter(d Dialect) *Writer {
+	return &Writer{Dialect: d}
+}
+
+// WriteQuoted writes an identifier, quoted by the dialect
+func (w *Writer) WriteQuoted(name string) {
+	if w.Dialect == nil || name == "*" {
+		w.WriteString(name)
+		return
+	}
+	w.WriteString(w.Dialect.Quote(name))
+}
+
+// AddVar writes values as bind vars; values that are themselves
+// expressions are rendered inline instead of being bound, compound raw
+// fragments in parentheses so that "? * 2" with "a + ?" stays (a + ?) * 2
+func (w *Writer) AddVar(values ...interface{}) {
+	for i, v := range values {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		if expr, ok := v.(Expression); ok {
+			raw, isRaw := expr.(Raw)
+			writeGrouped(w, expr, isRaw && isCompound(raw.SQL))
+			continue
+		}
+		w.Vars = append(w.Vars, v)
+		if w.Dialect == nil {
+			w.WriteByte('?')
+		} else {
+			w.WriteString(w.Dialect.BindVar(len(w.Vars)))
+		}
+	}
+}
+
+// Build renders expr and returns the SQL together with its vars
+func Build(d Dialect, expr Expression) (string, []interface{}) {
+	w := NewWriter(d)
+	expr.Build(w)
+	return w.String(), w.Vars
+}
+
+// Column references a column by name
+type Column struct {
+	Name string
+}
+
+func (c Column) Build(w *Writer) {
+	w.WriteQuoted(c.Name)
+}
+
+// Literal is a constant value, bound as a parameter
+type Literal struct {
+	Value interface{}
+}
+
+func (l Literal) Build(w *Writer) {
+	w.AddVar(l.Value)
+}
+
+// Raw is a SQL fragment with "?" placeholders, e.g. Raw{SQL: "age > ?", Vars: []interface{}{18}}.
+// A slice var expands to a parenthesised list so "id IN ?" works.
+// A "?" inside a string literal or a quoted identifier is not a placeholder.
+type Raw struct {
+	SQL  string
+	Vars []interface{}
+}
+
+func (r Raw) Build(w *Writer) {
+	idx := 0
+	var quote byte // 当前所在的引号，0 表示不在引号内
+	for i := 0; i < len(r.SQL); i++ {
+		ch := r.SQL[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			if quote == 0 {
+				quote = ch
+			} else if quote == ch {
+				quote = 0
+			}
+			w.WriteByte(ch)
+		case ch == '?' && quote == 0 && idx < len(r.Vars):
+			writeRawVar(w, r.Vars[idx])
+			idx++
+		default:
+			w.WriteByte(ch)
+		}
+	}
+	// 多余的参数保持原有行为，直接追加到 Vars
+	w.Vars = append(w.Vars, r.Vars[idx:]...)
+}
+
+func writeRawVar(w *Writer, v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		w.WriteByte('(')
+		if rv.Len() == 0 {
+			w.WriteString("NULL")
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.AddVar(rv.Index(i).Interface())
+		}
+		w.WriteByte(')')
+		return
+	}
+	w.AddVar(v)
+}
+
+// comparison renders "column op value"
+func comparison(w *Writer, column string, op string, value interface{}) {
+	w.WriteQuoted(column)
+	w.WriteString(" " + op + " ")
+	w.AddVar(value)
+}
+
+// Eq renders "column = value", or "column IS NULL" for a nil value
+type Eq struct {
+	Column string
+	Value  interface{}
+}
+
+func (e Eq) Build(w *Writer) {
+	if e.Value == nil {
+		IsNull{Column: e.Column}.Build(w)
+		return
+	}
+	comparison(w, e.Column, "=", e.Value)
+}
+
+// Neq renders "column <> value", or "column IS NOT NULL" for a nil value
+type Neq struct {
+	Column string
+	Value  interface{}
+}
+
+func (n Neq) Build(w *Writer) {
+	if n.Value == nil {
+		w.WriteQuoted(n.Column)
+		w.WriteString(" IS NOT NULL")
+		return
+	}
+	comparison(w, n.Column, "<>", n.Value)
+}
+
+// Gt renders "column > value"
+type Gt struct {
+	Column string
+	Value  interface{}
+}
+
+func (g Gt) Build(w *Writer) {
+	comparison(w, g.Column, ">", g.Value)
+}
+
+// Gte renders "column >= value"
+type Gte struct {
+	Column string
+	Value  interface{}
+}
+
+func (g Gte) Build(w *Writer) {
+	comparison(w, g.Column, ">=", g.Value)
+}
+
+// Lt renders "column < value"
+type Lt struct {
+	Column string
+	Value  interface{}
+}
+
+func (l Lt) Build(w *Writer) {
+	comparison(w, l.Column, "<", l.Value)
+}
+
+// Lte renders "column <= value"
+type Lte struct {
+	Column string
+	Value  interface{}
+}
+
+func (l Lte) Build(w *Writer) {
+	comparison(w, l.Column, "<=", l.Value)
+}
+
+// Like renders "column LIKE pattern"
+type Like struct {
+	Column  string
+	Pattern interface{}
+}
+
+func (l Like) Build(w *Writer) {
+	comparison(w, l.Column, "LIKE", l.Pattern)
+}
+
+// In renders "column IN (values...)"; an empty list matches nothing
+type In struct {
+	Column string
+	Values []interface{}
+}
+
+func (in In) Build(w *Writer) {
+	w.WriteQuoted(in.Column)
+	w.WriteString(" IN (")
+	if len(in.Values) == 0 {
+		w.WriteString("NULL")
+	} else {
+		w.AddVar(in.Values...)
+	}
+	w.WriteByte(')')
+}
+
+// Between renders "column BETWEEN low AND high"
+type Between struct {
+	Column string
+	Low    interface{}
+	High   interface{}
+}
+
+func (b Between) Build(w *Writer) {
+	w.WriteQuoted(b.Column)
+	w.WriteString(" BETWEEN ")
+	w.AddVar(b.Low)
+	w.WriteString(" AND ")
+	w.AddVar(b.High)
+}
+
+// IsNull renders "column IS NULL"
+type IsNull struct {
+	Column string
+}
+
+func (n IsNull) Build(w *Writer) {
+	w.WriteQuoted(n.Column)
+	w.WriteString(" IS NULL")
+}
+
+// AndExpr joins its expressions with AND
+type AndExpr struct {
+	Exprs []Expression
+}
+
+// And combines exprs with AND, flattening nested ANDs
+func And(exprs ...Expression) Expression {
+	var flat []Expression
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case nil:
+			continue
+		case AndExpr:
+			flat = append(flat, e.Exprs...)
+		default:
+			flat = append(flat, e)
+		}
+	}
+	switch len(flat) {
+	case 0:
+		return nil
+	case 1:
+		return flat[0]
+	}
+	return AndExpr{Exprs: flat}
+}
+
+func (a AndExpr) Build(w *Writer) {
+	joinExprs(w, " AND ", a.Exprs)
+}
+
+// OrExpr joins its expressions with OR
+type OrExpr struct {
+	Exprs []Expression
+}
+
+// Or combines exprs with OR, flattening nested ORs
+func Or(exprs ...Expression) Expression {
+	var flat []Expression
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case nil:
+			continue
+		case OrExpr:
+			flat = append(flat, e.Exprs...)
+		default:
+			flat = append(flat, e)
+		}
+	}
+	switch len(flat) {
+	case 0:
+		return nil
+	case 1:
+		return flat[0]
+	}
+	return OrExpr{Exprs: flat}
+}
+
+func (o OrExpr) Build(w *Writer) {
+	joinExprs(w, " OR ", o.Exprs)
+}
+
+// NotExpr negates its expression
+type NotExpr struct {
+	Expr Expression
+}
+
+// Not negates expr
+func Not(expr Expression) Expression {
+	return NotExpr{Expr: expr}
+}
+
+func (n NotExpr) Build(w *Writer) {
+	w.WriteString("NOT ")
+	writeGrouped(w, n.Expr, true)
+}
+
+// Where renders "WHERE expr1 AND expr2 ...", or nothing when Exprs is empty
+type Where struct {
+	Exprs []Expression
+}
+
+func (wh Where) Build(w *Writer) {
+	expr := And(wh.Exprs...)
+	if expr == nil {
+		return
+	}
+	w.WriteString("WHERE ")
+	expr.Build(w)
+}
+
+func joinExprs(w *Writer, sep string, exprs []Expression) {
+	for i, expr := range exprs {
+		if i > 0 {
+			w.WriteString(sep)
+		}
+		writeGrouped(w, expr, len(exprs) > 1)
+	}
+}
+
+// writeGrouped wraps compound expressions in parentheses so that
+// merging them into an outer AND/OR/NOT never changes their meaning
+func writeGrouped(w *Writer, expr Expression, group bool) {
+	if group && needsParens(expr) {
+		w.WriteByte('(')
+		expr.Build(w)
+		w.WriteByte(')')
+		return
+	}
+	expr.Build(w)
+}
+
+// isCompound reports whether a raw fragment contains operators outside
+// parentheses, as opposed to a single word, placeholder or function call
+func isCompound(sql string) bool {
+	sql = strings.TrimSpace(sql)
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		switch ch := sql[i]; {
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && !isWordByte(ch):
+			return true
+		}
+	}
+	return false
+}
+
+func isWordByte(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+		ch == '_' || ch == '.' || ch == '?' || ch == '"' || ch == '`'
+}
+
+func needsParens(expr Expression) bool {
+	switch e := expr.(type) {
+	case AndExpr:
+		return len(e.Exprs) > 1
+	case OrExpr:
+		return len(e.Exprs) > 1
+	case Raw:
+		return true
+	}
+	return false
+}
+
+// OnConflict renders the upsert clause of an INSERT, either
+// "ON CONFLICT (cols) DO NOTHING" or
+// "ON CONFLICT (cols) DO UPDATE SET col = excluded.col, ..."
+type OnConflict struct {
+	Columns   []string // conflict target
+	DoNothing bool
+	DoUpdates []string // columns overwritten with the values proposed for insertion
+}
+
+func (c OnConflict) Build(w *Writer) {
+	w.WriteString("ON CONFLICT")
+	if len(c.Columns) > 0 {
+		w.WriteString(" (")
+		for i, column := range c.Columns {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.WriteQuoted(column)
+		}
+		w.WriteByte(')')
+	}
+	if c.DoNothing || len(c.DoUpdates) == 0 {
+		w.WriteString(" DO NOTHING")
+		return
+	}
+	w.WriteString(" DO UPDATE SET ")
+	for i, column := range c.DoUpdates {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteQuoted(column)
+		w.WriteString(" = ")
+		w.WriteQuoted("excluded." + column)
+	}
+}
+
+// Expr builds a raw SQL expression with "?" placeholders that is rendered
+// inline wherever a value is expected, e.g. Expr("CURRENT_TIMESTAMP") or
+// Expr("? * 2", Column{Name: "score"})
+func Expr(sql string, vars ...interface{}) Raw {
+	return Raw{SQL: sql, Vars: vars}
+}
+
+// Increment renders "column + n"
+func Increment(column string, n interface{}) Raw {
+	return Expr("? + ?", Column{Name: column}, n)
+}
+
+// Decrement renders "column - n"
+func Decrement(column string, n interface{}) Raw {
+	return Expr("? - ?", Column{Name: column}, n)
+}
+
+// Assignment is a single "column = value" of an UPDATE; an Expression
+// value is rendered inline instead of being bound
+type Assignment struct {
+	Column string
+	Value  interface{}
+}
+
+// Assignments renders the SET clause of an UPDATE
+type Assignments []Assignment
+
+func (a Assignments) Build(w *Writer) {
+	w.WriteString("SET ")
+	for i, assignment := range a {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteQuoted(assignment.Column)
+		w.WriteString(" = ")
+		// 赋值的右侧是完整的表达式，不需要括号
+		if expr, ok := assignment.Value.(Expression); ok {
+			expr.Build(w)
+		} else {
+			w.AddVar(assignment.Value)
+		}
+	}
+}
+
+// When is one "WHEN value THEN result" branch of a Case
+type When struct {
+	Value interface{}
+	Then  interface{}
+}
+
+// Case renders "CASE column WHEN ? THEN ? ... [ELSE else] END"; a nil Else
+// is left out
+type Case struct {
+	Column string
+	Whens  []When
+	Else   interface{}
+}
+
+func (c Case) Build(w *Writer) {
+	w.WriteString("CASE ")
+	w.WriteQuoted(c.Column)
+	for _, when := range c.Whens {
+		w.WriteString(" WHEN ")
+		w.AddVar(when.Value)
+		w.WriteString(" THEN ")
+		w.AddVar(when.Then)
+	}
+	if c.Else != nil {
+		w.WriteString(" ELSE ")
+		w.AddVar(c.Else)
+	}
+	w.WriteString(" END")
+}
@@ -0,0 +1,150 @@
+package qsyclause
+
+import (
+	"reflect"
+	"testing"
+)
+
+// quoteDialect quotes identifiers with backticks and numbers bind vars
+type quoteDialect struct{}
+
+func (quoteDialect) BindVar(n int) string {
+	return "$" + string(rune('0'+n))
+}
+
+func (quoteDialect) Quote(name string) string {
+	return "`" + name + "`"
+}
+
+func TestExpressionBuild(t *testing.T) {
+	tests := []struct {
+		name string
+		expr Expression
+		sql  string
+		vars []interface{}
+	}{
+		{"Eq", Eq{Column: "age", Value: 18}, "age = ?", []interface{}{18}},
+		{"EqNil", Eq{Column: "age"}, "age IS NULL", nil},
+		{"Neq", Neq{Column: "age", Value: 18}, "age <> ?", []interface{}{18}},
+		{"Gt", Gt{Column: "age", Value: 18}, "age > ?", []interface{}{18}},
+		{"Lte", Lte{Column: "age", Value: 18}, "age <= ?", []interface{}{18}},
+		{"In", In{Column: "id", Values: []interface{}{1, 2}}, "id IN (?, ?)", []interface{}{1, 2}},
+		{"InEmpty", In{Column: "id"}, "id IN (NULL)", nil},
+		{"Between", Between{Column: "age", Low: 1, High: 9}, "age BETWEEN ? AND ?", []interface{}{1, 9}},
+		{"Like", Like{Column: "name", Pattern: "a%"}, "name LIKE ?", []interface{}{"a%"}},
+		{"IsNull", IsNull{Column: "deleted"}, "deleted IS NULL", nil},
+		{"ColumnValue", Gt{Column: "a", Value: Column{Name: "b"}}, "a > b", nil},
+		{"RawSlice", Raw{SQL: "id IN ?", Vars: []interface{}{[]int{1, 2}}}, "id IN (?, ?)", []interface{}{1, 2}},
+		{"RawQuoted", Raw{SQL: "name = '?' AND age = ?", Vars: []interface{}{3}}, "name = '?' AND age = ?", []interface{}{3}},
+		{"RawQuotedIdent", Raw{SQL: `"a?" = ? AND b = '"' AND c = ?`, Vars: []interface{}{1, 2}}, `"a?" = ? AND b = '"' AND c = ?`, []interface{}{1, 2}},
+		{
+			"AndOr",
+			And(Raw{SQL: "a = ? OR b = ?", Vars: []interface{}{1, 2}}, Or(Eq{Column: "c", Value: 3}, Eq{Column: "d", Value: 4})),
+			"(a = ? OR b = ?) AND (c = ? OR d = ?)",
+			[]interface{}{1, 2, 3, 4},
+		},
+		{
+			"Not",
+			Not(And(Eq{Column: "a", Value: 1}, Eq{Column: "b", Value: 2})),
+			"NOT (a = ? AND b = ?)",
+			[]interface{}{1, 2},
+		},
+		{"Flatten", And(And(Eq{Column: "a", Value: 1}), nil, Eq{Column: "b", Value: 2}), "a = ? AND b = ?", []interface{}{1, 2}},
+		{"Where", Where{Exprs: []Expression{Eq{Column: "a", Value: 1}}}, "WHERE a = ?", []interface{}{1}},
+		{"WhereEmpty", Where{}, "", nil},
+		{"Increment", Increment("views", 1), "views + ?", []interface{}{1}},
+		{"Decrement", Decrement("stock", 2), "stock - ?", []interface{}{2}},
+		{"NestedExpr", Expr("? * 2", Increment("score", 1)), "(score + ?) * 2", []interface{}{1}},
+		{"NestedWord", Expr("? + 1", Expr("CURRENT_TIMESTAMP")), "CURRENT_TIMESTAMP + 1", nil},
+		{"ValueExpr", Eq{Column: "a", Value: Expr("b OR c")}, "a = (b OR c)", nil},
+		{
+			"Assignments",
+			Assignments{{Column: "name", Value: "x"}, {Column: "views", Value: Increment("views", 1)}, {Column: "updated", Value: Expr("CURRENT_TIMESTAMP")}},
+			"SET name = ?, views = views + ?, updated = CURRENT_TIMESTAMP",
+			[]interface{}{"x", 1},
+		},
+		{
+			"AssignNested",
+			Assignments{{Column: "score", Value: Expr("? * 2", Increment("score", 1))}},
+			"SET score = (score + ?) * 2",
+			[]interface{}{1},
+		},
+		{
+			"Case",
+			Case{Column: "id", Whens: []When{{Value: 1, Then: "a"}, {Value: 2, Then: "b"}}, Else: Column{Name: "name"}},
+			"CASE id WHEN ? THEN ? WHEN ? THEN ? ELSE name END",
+			[]interface{}{1, "a", 2, "b"},
+		},
+		{"OnConflictNothing", OnConflict{Columns: []string{"id"}, DoNothing: true}, "ON CONFLICT (id) DO NOTHING", nil},
+		{
+			"OnConflictUpdate",
+			OnConflict{Columns: []string{"email"}, DoUpdates: []string{"name", "age"}},
+			"ON CONFLICT (email) DO UPDATE SET name = excluded.name, age = excluded.age",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars := Build(nil, tt.expr)
+			if sql != tt.sql {
+				t.Errorf("Expected SQL to be '%s', got '%s'", tt.sql, sql)
+			}
+			if !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("Expected Vars to be %v, got %v", tt.vars, vars)
+			}
+		})
+	}
+}
+
+func TestExpressionDialect(t *testing.T) {
+	expr := And(Eq{Column: "name", Value: "John"}, Gt{Column: "age", Value: 18})
+	sql, vars := Build(quoteDialect{}, expr)
+
+	expectedSQL := "`name` = $1 AND `age` > $2"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL to be '%s', got '%s'", expectedSQL, sql)
+	}
+
+	expectedVars := []interface{}{"John", 18}
+	if !reflect.DeepEqual(vars, expectedVars) {
+		t.Errorf("Expected Vars to be %v, got %v", expectedVars, vars)
+	}
+}
+
+func TestBuilderExpression(t *testing.T) {
+	builder := New().WithDialect(quoteDialect{})
+	builder.Set(SELECT, "SELECT * FROM users")
+	builder.Set(WHERE, Where{Exprs: []Expression{Eq{Column: "age", Value: 18}}})
+	builder.Set(LIMIT, Where{})
+
+	sql, vars := builder.Build(SELECT, WHERE, LIMIT)
+
+	expectedSQL := "SELECT * FROM users WHERE `age` = $1"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL to be '%s', got '%s'", expectedSQL, sql)
+	}
+
+	expectedVars := []interface{}{18}
+	if !reflect.DeepEqual(vars, expectedVars) {
+		t.Errorf("Expected Vars to be %v, got %v", expectedVars, vars)
+	}
+
+	// 字符串子句的参数同样按方言编号
+	builder.Set(LIMIT, "LIMIT ?", 10)
+	builder.Set(OFFSET, "OFFSET ?", 20)
+	sql, vars = builder.Build(SELECT, WHERE, LIMIT, OFFSET)
+	if expectedSQL := "SELECT * FROM users WHERE `age` = $1 LIMIT $2 OFFSET $3"; sql != expectedSQL {
+		t.Errorf("Expected SQL to be '%s', got '%s'", expectedSQL, sql)
+	}
+	if expectedVars := []interface{}{18, 10, 20}; !reflect.DeepEqual(vars, expectedVars) {
+		t.Errorf("Expected Vars to be %v, got %v", expectedVars, vars)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Set to panic on unsupported clause value")
+		}
+	}()
+	builder.Set(WHERE, 42)
+}
@@ -22,18 +22,24 @@ const (
 	SET
 	FROM
 	JOIN
+	OFFSET
+	ONCONFLICT
 )
 
-// Clause represents a SQL clause with its values
+// Clause represents a SQL clause with its values.
+// When Expression is set it is rendered at Build time instead of SQL;
+// otherwise SQL is rendered as a Raw fragment with Vars.
 type Clause struct {
-	Type Type
-	SQL  string
-	Vars []interface{}
+	Type       Type
+	SQL        string
+	Vars       []interface{}
+	Expression Expression
 }
 
 // Builder builds SQL statements by combining clauses
 type Builder struct {
 	clauses map[Type]Clause
+	dialect Dialect
 }
 
 // New creates a new clause builder
@@ -43,37 +49,61 @@ func New() *Builder {
 	}
 }
 
-// Set adds a clause to the builder
+// WithDialect sets the dialect used to render expression clauses
+func (b *Builder) WithDialect(d Dialect) *Builder {
+	b.dialect = d
+	return b
+}
+
+// Set adds a clause to the builder. The clause is either a SQL string
+// followed by its vars, or a single Expression.
 func (b *Builder) Set(name Type, clause ...interface{}) {
-	var vars []interface{}
-	var sql string
+	c := Clause{Type: name}
 
 	// Extract SQL and variables from the clause
 	if len(clause) > 0 {
-		sql = clause[0].(string)
-		vars = clause[1:]
+		switch v := clause[0].(type) {
+		case string:
+			c.SQL = v
+			c.Vars = clause[1:]
+		case Expression:
+			c.Expression = v
+		default:
+			panic(fmt.Sprintf("qsyclause: unsupported clause %T for type %d", v, name))
+		}
 	}
 
-	b.clauses[name] = Clause{
-		Type: name,
-		SQL:  sql,
-		Vars: vars,
-	}
+	b.clauses[name] = c
 }
 
 // Build constructs the final SQL statement based on specified clause types
 func (b *Builder) Build(orders ...Type) (string, []interface{}) {
-	var sqls []string
-	var vars []interface{}
+	w := NewWriter(b.dialect)
 
 	for _, order := range orders {
-		if clause, ok := b.clauses[order]; ok {
-			sqls = append(sqls, clause.SQL)
-			vars = append(vars, clause.Vars...)
+		clause, ok := b.clauses[order]
+		if !ok {
+			continue
+		}
+		mark := w.Len()
+		if mark > 0 {
+			w.WriteByte(' ')
+		}
+		if clause.Expression == nil {
+			// 字符串子句同样经过 Writer 绑定参数，占位符按方言编号
+			Raw{SQL: clause.SQL, Vars: clause.Vars}.Build(w)
+		} else {
+			clause.Expression.Build(w)
+		}
+		// 表达式可能什么都不输出（例如空的 WHERE），此时去掉多余的空格
+		if w.Len() == mark+1 {
+			s := w.String()[:mark]
+			w.Reset()
+			w.WriteString(s)
 		}
 	}
 
-	return strings.Join(sqls, " "), vars
+	return w.String(), w.Vars
 }
 
 // BuildInsert builds an INSERT statement
@@ -89,14 +119,41 @@ func BuildInsert(table string, fields []string) (string, []interface{}) {
 		strings.Join(placeholders, ", ")), nil
 }
 
-// BuildValues builds a VALUES clause for batch insert
+// BuildInsertInto builds the "INSERT INTO table (fields)" part of an insert
+// whose rows are supplied by BuildValues
+func BuildInsertInto(table string, fields []string) (string, []interface{}) {
+	return fmt.Sprintf("INSERT INTO %s (%s)", table, strings.Join(fields, ", ")), nil
+}
+
+// BuildValues builds a VALUES clause. Plain values form a single row;
+// when every argument is a []interface{} each one is a row of a batch insert,
+// e.g. BuildValues([]interface{}{"a", 1}, []interface{}{"b", 2}) gives "VALUES (?, ?), (?, ?)".
 func BuildValues(values ...interface{}) (string, []interface{}) {
-	var bindStr string
-	if len(values) > 0 {
-		bindStr = strings.Repeat("?, ", len(values))
-		bindStr = bindStr[:len(bindStr)-2] // Remove trailing ", "
+	rows := make([][]interface{}, 0, len(values))
+	for _, value := range values {
+		row, ok := value.([]interface{})
+		if !ok {
+			rows = [][]interface{}{values}
+			break
+		}
+		rows = append(rows, row)
 	}
-	return fmt.Sprintf("VALUES (%s)", bindStr), values
+	if len(rows) == 0 {
+		rows = append(rows, nil)
+	}
+
+	var tuples []string
+	var vars []interface{}
+	for _, row := range rows {
+		var bindStr string
+		if len(row) > 0 {
+			bindStr = strings.Repeat("?, ", len(row))
+			bindStr = bindStr[:len(bindStr)-2] // Remove trailing ", "
+		}
+		tuples = append(tuples, fmt.Sprintf("(%s)", bindStr))
+		vars = append(vars, row...)
+	}
+	return fmt.Sprintf("VALUES %s", strings.Join(tuples, ", ")), vars
 }
 
 // BuildSelect builds a SELECT statement
@@ -117,6 +174,11 @@ func BuildLimit(limit int) (string, []interface{}) {
 	return "LIMIT ?", []interface{}{limit}
 }
 
+// BuildOffset builds an OFFSET clause
+func BuildOffset(offset int) (string, []interface{}) {
+	return "OFFSET ?", []interface{}{offset}
+}
+
 // BuildOrderBy builds an ORDER BY clause
 func BuildOrderBy(field string, desc bool) (string, []interface{}) {
 	order := "ASC"
@@ -126,6 +188,11 @@ func BuildOrderBy(field string, desc bool) (string, []interface{}) {
 	return fmt.Sprintf("ORDER BY %s %s", field, order), nil
 }
 
+// BuildOrders builds an ORDER BY clause from several "column [ASC|DESC]" items
+func BuildOrders(orders []string) (string, []interface{}) {
+	return fmt.Sprintf("ORDER BY %s", strings.Join(orders, ", ")), nil
+}
+
 // BuildUpdate builds an UPDATE statement
 func BuildUpdate(table string, fields []string) (string, []interface{}) {
 	var setStrs []string
@@ -135,6 +202,12 @@ func BuildUpdate(table string, fields []string) (string, []interface{}) {
 	return fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(setStrs, ", ")), nil
 }
 
+// BuildUpdateTable builds the "UPDATE table" part of an update whose
+// SET clause is rendered by Assignments
+func BuildUpdateTable(table string) (string, []interface{}) {
+	return fmt.Sprintf("UPDATE %s", table), nil
+}
+
 // BuildDelete builds a DELETE statement
 func BuildDelete(table string) (string, []interface{}) {
 	return fmt.Sprintf("DELETE FROM %s", table), nil
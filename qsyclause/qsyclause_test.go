@@ -84,6 +84,33 @@ func TestBuildValues(t *testing.T) {
 	}
 }
 
+func TestBuildValuesBatch(t *testing.T) {
+	sql, vars := BuildValues([]interface{}{"John", 25}, []interface{}{"Jane", 30})
+
+	expectedSQL := "VALUES (?, ?), (?, ?)"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL to be '%s', got '%s'", expectedSQL, sql)
+	}
+
+	expectedVars := []interface{}{"John", 25, "Jane", 30}
+	if !reflect.DeepEqual(vars, expectedVars) {
+		t.Errorf("Expected Vars to be %v, got %v", expectedVars, vars)
+	}
+}
+
+func TestBuildInsertInto(t *testing.T) {
+	sql, vars := BuildInsertInto("users", []string{"name", "age"})
+
+	expectedSQL := "INSERT INTO users (name, age)"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL to be '%s', got '%s'", expectedSQL, sql)
+	}
+
+	if vars != nil {
+		t.Errorf("Expected Vars to be nil, got %v", vars)
+	}
+}
+
 func TestBuildSelect(t *testing.T) {
 	sql, vars := BuildSelect("users", []string{"name", "age"}, "WHERE age > 18")
 
@@ -145,6 +172,33 @@ func TestBuildOrderBy(t *testing.T) {
 	}
 }
 
+func TestBuildOffset(t *testing.T) {
+	sql, vars := BuildOffset(20)
+
+	expectedSQL := "OFFSET ?"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL to be '%s', got '%s'", expectedSQL, sql)
+	}
+
+	expectedVars := []interface{}{20}
+	if !reflect.DeepEqual(vars, expectedVars) {
+		t.Errorf("Expected Vars to be %v, got %v", expectedVars, vars)
+	}
+}
+
+func TestBuildOrders(t *testing.T) {
+	sql, vars := BuildOrders([]string{"age DESC", "name"})
+
+	expectedSQL := "ORDER BY age DESC, name"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL to be '%s', got '%s'", expectedSQL, sql)
+	}
+
+	if vars != nil {
+		t.Errorf("Expected Vars to be nil, got %v", vars)
+	}
+}
+
 func TestBuildUpdate(t *testing.T) {
 	sql, vars := BuildUpdate("users", []string{"name", "age"})
 
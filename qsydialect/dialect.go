@@ -12,6 +12,29 @@ type Dialect interface {
 	//SQL
 	DataTypeOf(typ reflect.Value) string
 	TableExist(tableName string) (string, interface{})
+	// BindVar returns the placeholder for the n-th (1-based) bind var
+	BindVar(n int) string
+	// Quote quotes a table or column identifier
+	Quote(name string) string
+	// ColumnDefinition renders one column of a CREATE TABLE statement
+	ColumnDefinition(c Column) string
+}
+
+// Column describes a table column for DDL
+type Column struct {
+	Name          string
+	Type          string // DataTypeOf result or the explicit type tag
+	Size          int    // length of string columns
+	Precision     int    // total digits of decimal columns
+	Scale         int    // digits after the decimal point
+	PrimaryKey    bool
+	AutoIncrement bool
+	NotNull       bool
+	Unique        bool
+	HasDefault    bool
+	Default       string // SQL expression, rendered as is
+	Check         string // CHECK expression
+	Comment       string
 }
 
 func RegisterDialect(name string, d Dialect) {
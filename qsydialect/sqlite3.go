@@ -1,8 +1,10 @@
 package qsydialect
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -31,9 +33,12 @@ func (s *sqlite3) DataTypeOf(d reflect.Value) string {
 		if d.Type().Elem().Kind() == reflect.Uint8 {
 			return "BINARY"
 		}
+	//pointers are nullable columns of the element type
+	case reflect.Ptr:
+		return s.DataTypeOf(reflect.New(d.Type().Elem()).Elem())
 	//tackle with the time.Time Struct
 	case reflect.Struct:
-		if d.Type() == reflect.TypeOf(time.Time{}) {
+		if d.Type() == reflect.TypeOf(time.Time{}) || d.Type() == reflect.TypeOf(sql.NullTime{}) {
 			return "DATETIME"
 		}
 		panic(fmt.Sprintf("invalid sql type %s (%s)", d.Type().Name(), d.Kind()))
@@ -46,3 +51,54 @@ func (s *sqlite3) TableExist(tableName string) (string, interface{}) {
 	query := "SELECT name FROM sqlite_master WHERE type='table' AND name=?"
 	return query, tableName
 }
+
+func (s *sqlite3) BindVar(n int) string {
+	return "?"
+}
+
+// Quote wraps every part of a dotted identifier in double quotes
+func (s *sqlite3) Quote(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// ColumnDefinition renders c for CREATE TABLE with the name quoted, so
+// reserved words can be used as column names. SQLite has no column
+// comments, so the comment is kept as a /* */ comment in the stored DDL
+func (s *sqlite3) ColumnDefinition(c Column) string {
+	typ := c.Type
+	switch {
+	case c.Size > 0 && typ == "TEXT":
+		typ = fmt.Sprintf("VARCHAR(%d)", c.Size)
+	case c.Precision > 0 && typ == "REAL":
+		typ = fmt.Sprintf("DECIMAL(%d,%d)", c.Precision, c.Scale)
+	}
+
+	parts := []string{s.Quote(c.Name), typ}
+	// SQLite 要求 AUTOINCREMENT 必须按照 INTEGER PRIMARY KEY AUTOINCREMENT 顺序，
+	// BIGINT 主键也只有写成 INTEGER 才是 rowid 的别名
+	if c.PrimaryKey && c.AutoIncrement && (strings.EqualFold(typ, "INTEGER") || strings.EqualFold(typ, "BIGINT")) {
+		parts = []string{s.Quote(c.Name), "INTEGER PRIMARY KEY AUTOINCREMENT"}
+	} else if c.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	}
+	if c.NotNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if c.HasDefault {
+		parts = append(parts, "DEFAULT "+c.Default)
+	}
+	if c.Check != "" {
+		parts = append(parts, "CHECK ("+c.Check+")")
+	}
+	if c.Comment != "" {
+		parts = append(parts, "/* "+strings.ReplaceAll(c.Comment, "*/", "* /")+" */")
+	}
+	return strings.Join(parts, " ")
+}
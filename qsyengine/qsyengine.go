@@ -1,17 +1,23 @@
 package qsyengine
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"qsyorm/qsydialect"
 	"qsyorm/qsylog"
+	"qsyorm/qsyschema"
 	"qsyorm/qsysession"
+	"time"
 )
 
 type QSyEngine struct {
 	db      *sql.DB
 	logger  qsylog.Interface
 	dialect qsydialect.Dialect
+	timeout time.Duration
+	nowFunc func() time.Time
+	namer   qsyschema.Namer
 }
 
 func NewQSyEngine(driver, source string, log qsylog.Interface) (e *QSyEngine, err error) {
@@ -47,8 +53,47 @@ func (engine *QSyEngine) Close() {
 	_ = engine.db.Close()
 }
 
+// Dialect returns the dialect of the engine's driver
+func (engine *QSyEngine) Dialect() qsydialect.Dialect {
+	return engine.dialect
+}
+
 func (engine *QSyEngine) NewSession() *qsysession.Session {
-	return qsysession.NewSession(engine.db, engine.logger, engine.dialect)
+	return qsysession.NewSession(engine.db, engine.logger, engine.dialect).
+		WithTimeout(engine.timeout).
+		WithClock(engine.nowFunc).
+		WithNamer(engine.namer)
+}
+
+// NewSessionContext creates a session whose statements, transactions,
+// hooks and logger all receive ctx
+func (engine *QSyEngine) NewSessionContext(ctx context.Context) *qsysession.Session {
+	return engine.NewSession().WithContext(ctx)
+}
+
+// Query starts a typed query for model T on a new session,
+// e.g. qsyengine.Query[User](engine).Where("Age > ?", 18).Find(ctx)
+func Query[T any](engine *QSyEngine) *qsysession.TypedQuery[T] {
+	return qsysession.Typed[T](engine.NewSession())
+}
+
+// SetQueryTimeout sets the default per-statement timeout of new sessions
+func (engine *QSyEngine) SetQueryTimeout(timeout time.Duration) {
+	engine.timeout = timeout
+}
+
+// SetClock sets the clock new sessions use for CreatedAt/UpdatedAt and
+// soft deletion, e.g. a fixed time in tests; nil restores time.Now
+func (engine *QSyEngine) SetClock(now func() time.Time) {
+	engine.nowFunc = now
+}
+
+// SetNamingStrategy sets how new sessions name the tables and untagged
+// columns of models, e.g. qsyschema.NamingStrategy{SnakeCase: true,
+// PluralTables: true} maps UserID of User to users.user_id.
+// nil keeps the default: lowercase struct names and Go field names.
+func (engine *QSyEngine) SetNamingStrategy(namer qsyschema.Namer) {
+	engine.namer = namer
 }
 
 // Migrate 自动将结构体映射为数据库表
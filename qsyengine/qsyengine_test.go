@@ -1,7 +1,9 @@
 package qsyengine
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -380,3 +382,53 @@ func printModelInfo(t *testing.T, engine *QSyEngine, model interface{}) {
 			i, field.Name, field.Type, field.IsPrimaryKey, field.IsAutoIncrement, field.Index, field.Unique)
 	}
 }
+
+func TestNewSessionContext(t *testing.T) {
+	dbFile := fmt.Sprintf("test_context_%d.db", time.Now().UnixNano())
+	defer os.Remove(dbFile)
+
+	engine, err := NewQSyEngine("sqlite3", dbFile, qsylog.Discard)
+	if err != nil {
+		t.Fatal("failed to create engine:", err)
+	}
+	defer engine.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := engine.NewSessionContext(ctx)
+	if session.Context() != ctx {
+		t.Fatal("session does not carry the engine context")
+	}
+	cancel()
+	if _, err := session.Raw("SELECT 1").Exec(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	engine.SetQueryTimeout(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, err := engine.NewSession().Raw("SELECT 1").Exec(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	dbFile := fmt.Sprintf("test_query_%d.db", time.Now().UnixNano())
+	defer os.Remove(dbFile)
+
+	engine, err := NewQSyEngine("sqlite3", dbFile, qsylog.Discard)
+	if err != nil {
+		t.Fatal("failed to create engine:", err)
+	}
+	defer engine.Close()
+	if err := engine.Migrate(&User{}); err != nil {
+		t.Fatal("failed to migrate User model:", err)
+	}
+
+	ctx := context.Background()
+	if _, err := Query[User](engine).Insert(ctx, &User{Name: "Tom", Age: 18}); err != nil {
+		t.Fatal("failed to insert:", err)
+	}
+	users, err := Query[User](engine).Where("Age >= ?", 18).Find(ctx)
+	if err != nil || len(users) != 1 || users[0].Name != "Tom" {
+		t.Fatalf("unexpected result: %v, %v", users, err)
+	}
+}
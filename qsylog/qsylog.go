@@ -47,6 +47,7 @@ package qsylog
 
 //the package used in qsylog
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -89,6 +90,13 @@ type Interface interface {
 	Error(s string, v ...interface{})
 }
 
+// optional interface for loggers that want the request context,
+// e.g. to print a request id; sessions call WithContext before logging
+type ContextInterface interface {
+	Interface
+	WithContext(ctx context.Context) Interface
+}
+
 var (
 	//for the logger which is discard
 	Discard = New(log.New(io.Discard, "", log.LstdFlags), Config{})
@@ -0,0 +1,149 @@
+package qsyschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Index is a table index built from the index and uniqueIndex tags.
+// Fields tagged with the same index name form one composite index:
+//
+//	Name string `qsy:"index:idx_user_age,priority:1"`
+//	Age  int    `qsy:"index:idx_user_age,priority:2,sort:desc"`
+type Index struct {
+	Name   string
+	Unique bool
+	Where  string // 部分索引的条件，where:deleted_at IS NULL
+	Fields []IndexField
+}
+
+// IndexField is one column or expression of an index
+type IndexField struct {
+	Field      *Field
+	Expression string // 表达式索引，例如 expression:lower(username)，为空时使用列名
+	Sort       string // ASC、DESC 或空
+	Priority   int    // 在复合索引中的位置，越小越靠前，默认10
+}
+
+// Column returns the indexed column or expression with its sort direction
+func (f IndexField) Column() string {
+	column := f.Expression
+	if column == "" {
+		column = f.Field.DBName
+	}
+	if f.Sort != "" {
+		column += " " + f.Sort
+	}
+	return column
+}
+
+// LookupIndex returns the index called name, or nil
+func (s *Schema) LookupIndex(name string) *Index {
+	for _, index := range s.Indexes {
+		if index.Name == name {
+			return index
+		}
+	}
+	return nil
+}
+
+// indexSettings are the options an index tag accepts after the name
+var indexSettings = []string{"priority", "sort", "where", "expression", "unique"}
+
+// parseIndexes builds Schema.Indexes from the tags of the parsed fields,
+// keeping the indexes in the order their first field appears
+func (s *Schema) parseIndexes() {
+	for _, field := range s.Fields {
+		tags := s.parseTag(field.Tag)
+		for _, key := range []string{"index", "uniqueIndex"} {
+			value, ok := tags[key]
+			if !ok {
+				continue
+			}
+			name, settings := parseIndexTag(value)
+			if name == "" {
+				name = fmt.Sprintf("idx_%s_%s", s.GetTableName(), strings.ToLower(field.DBName))
+			}
+
+			index := s.LookupIndex(name)
+			if index == nil {
+				index = &Index{Name: name}
+				s.Indexes = append(s.Indexes, index)
+			}
+			if _, unique := settings["unique"]; unique || key == "uniqueIndex" {
+				index.Unique = true
+			}
+			if where := settings["where"]; where != "" && index.Where == "" {
+				index.Where = where
+			}
+			index.Fields = append(index.Fields, IndexField{
+				Field:      field,
+				Expression: settings["expression"],
+				Sort:       s.indexSort(field, settings["sort"]),
+				Priority:   s.indexPriority(field, settings["priority"]),
+			})
+		}
+	}
+
+	for _, index := range s.Indexes {
+		sort.SliceStable(index.Fields, func(i, j int) bool {
+			return index.Fields[i].Priority < index.Fields[j].Priority
+		})
+	}
+}
+
+// parseIndexTag splits "idx_name,priority:2,where:a IN (1,2)" into the
+// index name and its settings; a comma not followed by a known setting
+// belongs to the previous value
+func parseIndexTag(value string) (string, map[string]string) {
+	settings := make(map[string]string)
+	var name, last string
+	for i, part := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), ":")
+		switch {
+		case i == 0 && !isIndexSetting(key):
+			name = strings.TrimSpace(part)
+			last = ""
+		case isIndexSetting(key):
+			settings[key] = strings.TrimSpace(val)
+			last = key
+		case last != "":
+			settings[last] += "," + part
+		default:
+			name += "," + part
+		}
+	}
+	return name, settings
+}
+
+func isIndexSetting(key string) bool {
+	for _, setting := range indexSettings {
+		if key == setting {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) indexSort(field *Field, value string) string {
+	switch strings.ToUpper(value) {
+	case "":
+		return ""
+	case "ASC", "DESC":
+		return strings.ToUpper(value)
+	}
+	panic(fmt.Sprintf("qsyschema: invalid index sort %q on %s.%s", value, s.Name, field.Name))
+}
+
+func (s *Schema) indexPriority(field *Field, value string) int {
+	if value == "" {
+		return 10
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Sprintf("qsyschema: invalid index priority %q on %s.%s", value, s.Name, field.Name))
+	}
+	return priority
+}
@@ -0,0 +1,56 @@
+package qsyschema
+
+import (
+	"testing"
+)
+
+func TestParseIndexes(t *testing.T) {
+	type Member struct {
+		ID        int64  `qsy:"primarykey;autoincrement"`
+		Username  string `qsy:"uniqueIndex;index:idx_member_lower,expression:lower(Username)"`
+		Age       int    `qsy:"index:idx_member_age,priority:2,sort:desc"`
+		City      string `qsy:"index:idx_member_age,priority:1"`
+		Email     string `qsy:"index:idx_member_email,unique,where:DeletedAt IS NULL AND Status IN (1,2)"`
+		Status    int
+		DeletedAt *int64
+	}
+
+	schema := Parse(&Member{}, testDialect)
+	if len(schema.Indexes) != 4 {
+		t.Fatalf("expected 4 indexes, got %d", len(schema.Indexes))
+	}
+
+	unique := schema.LookupIndex("idx_member_username")
+	if unique == nil || !unique.Unique || len(unique.Fields) != 1 {
+		t.Fatalf("unique index not parsed: %+v", unique)
+	}
+	if lower := schema.LookupIndex("idx_member_lower"); lower == nil || lower.Unique || lower.Fields[0].Column() != "lower(Username)" {
+		t.Fatalf("expression index not parsed: %+v", lower)
+	}
+
+	composite := schema.LookupIndex("idx_member_age")
+	if composite == nil || len(composite.Fields) != 2 {
+		t.Fatalf("composite index not parsed: %+v", composite)
+	}
+	if composite.Fields[0].Column() != "City" || composite.Fields[1].Column() != "Age DESC" {
+		t.Fatalf("composite index columns out of order: %s, %s", composite.Fields[0].Column(), composite.Fields[1].Column())
+	}
+
+	partial := schema.LookupIndex("idx_member_email")
+	if partial == nil || !partial.Unique || partial.Where != "DeletedAt IS NULL AND Status IN (1,2)" {
+		t.Fatalf("partial index not parsed: %+v", partial)
+	}
+	if !schema.GetField("Username").Index || schema.GetField("Username").Unique {
+		t.Fatal("uniqueIndex should mark the field indexed without a UNIQUE column constraint")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected invalid sort to panic")
+		}
+	}()
+	type Bad struct {
+		Name string `qsy:"index:idx_bad,sort:up"`
+	}
+	Parse(&Bad{}, testDialect)
+}
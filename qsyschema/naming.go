@@ -0,0 +1,73 @@
+package qsyschema
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Namer turns Go struct and field names into table and column names.
+// Fields tagged with name keep the tagged column name.
+type Namer interface {
+	TableName(structName string) string
+	ColumnName(fieldName string) string
+}
+
+// NamingStrategy is the built-in Namer. Its zero value keeps the historical
+// names: the lowercase struct name for tables and the Go field name for
+// columns.
+type NamingStrategy struct {
+	TablePrefix  string // prepended to table names, e.g. "app_"
+	PluralTables bool   // pluralise table names: user -> users
+	SnakeCase    bool   // snake_case tables and columns: UserID -> user_id
+}
+
+var _ Namer = NamingStrategy{}
+
+func (n NamingStrategy) TableName(structName string) string {
+	name := strings.ToLower(structName)
+	if n.SnakeCase {
+		name = ToSnakeCase(structName)
+	}
+	if n.PluralTables {
+		name = plural(name)
+	}
+	return n.TablePrefix + name
+}
+
+func (n NamingStrategy) ColumnName(fieldName string) string {
+	if n.SnakeCase {
+		return ToSnakeCase(fieldName)
+	}
+	return fieldName
+}
+
+// ToSnakeCase converts a Go identifier to snake_case, keeping acronyms
+// together: UserID -> user_id, HTTPServer -> http_server
+func ToSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) && runes[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// plural returns the English plural of a lowercase table name
+func plural(name string) string {
+	switch {
+	case name == "":
+		return name
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
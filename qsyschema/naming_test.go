@@ -0,0 +1,71 @@
+package qsyschema
+
+import "testing"
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"CreatedAt":  "created_at",
+		"HTTPServer": "http_server",
+		"Age":        "age",
+		"already_ok": "already_ok",
+		"Address2":   "address2",
+	}
+	for in, want := range tests {
+		if got := ToSnakeCase(in); got != want {
+			t.Errorf("ToSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNamingStrategy(t *testing.T) {
+	type OrderItem struct {
+		ID      int `qsy:"primarykey"`
+		OrderID int
+		Label   string `qsy:"name:item_label"`
+	}
+
+	schema := Parse(&OrderItem{}, testDialect)
+	if schema.GetTableName() != "orderitem" || schema.FieldMap["OrderID"].DBName != "OrderID" {
+		t.Fatalf("default naming changed: %s, %s", schema.GetTableName(), schema.FieldMap["OrderID"].DBName)
+	}
+	if field := schema.FieldMap["Label"]; field.Name != "Label" || field.DBName != "item_label" {
+		t.Fatalf("name tag not used as column: %+v", field)
+	}
+	if schema.DbFieldToGo["item_label"] != "Label" {
+		t.Fatal("column name not mapped to Go field")
+	}
+
+	schema = ParseWithNamer(&OrderItem{}, testDialect, NamingStrategy{TablePrefix: "shop_", PluralTables: true, SnakeCase: true})
+	if schema.GetTableName() != "shop_order_items" {
+		t.Fatalf("unexpected table name %s", schema.GetTableName())
+	}
+	if schema.FieldMap["OrderID"].DBName != "order_id" || schema.FieldMap["Label"].DBName != "item_label" {
+		t.Fatalf("unexpected columns %v", schema.FieldNames)
+	}
+
+	for in, want := range map[string]string{"category": "categories", "box": "boxes", "key": "keys", "user": "users"} {
+		if got := plural(in); got != want {
+			t.Errorf("plural(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+type UserProfile struct {
+	ID int `qsy:"primarykey"`
+}
+
+func (UserProfile) TableName() string {
+	return "user_profiles"
+}
+
+func TestTabler(t *testing.T) {
+	if name := Parse(&UserProfile{}, testDialect).GetTableName(); name != "user_profiles" {
+		t.Fatalf("TableName() not honoured: %s", name)
+	}
+	// TableName() 优先于命名策略
+	if name := ParseWithNamer(UserProfile{}, testDialect, NamingStrategy{TablePrefix: "x_"}).GetTableName(); name != "user_profiles" {
+		t.Fatalf("TableName() should override the naming strategy: %s", name)
+	}
+}
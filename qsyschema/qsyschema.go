@@ -1,47 +1,128 @@
 package qsyschema
 
 import (
+	"database/sql"
+	"fmt"
 	"go/ast"
 	"qsyorm/qsyclause"
 	"qsyorm/qsydialect"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// AutoTime tells how a timestamp maintained by the session is stored
+type AutoTime int
+
+const (
+	AutoTimeNone      AutoTime = iota
+	AutoTimeValue              // time.Time or *time.Time
+	AutoTimeUnix               // integer unix seconds
+	AutoTimeUnixMilli          // integer unix milliseconds, tag value ":milli"
 )
 
 type Field struct {
-	Name            string
+	Name            string // Go 字段名
+	DBName          string // 数据库列名，来自 name 标签或命名策略
 	Type            string
+	GoType          reflect.Type // 结构体字段的 Go 类型
 	Tag             string
 	IsPrimaryKey    bool
 	IsAutoIncrement bool
 	Index           bool
 	Unique          bool
+	NotNull         bool
+	HasDefault      bool
+	Default         string // 列默认值的SQL表达式，字符串字段会自动加引号
+	Size            int    // 字符串列的长度，size:255
+	Precision       int    // 小数列的总位数，precision:10
+	Scale           int    // 小数列的小数位数，scale:2
+	Check           string // CHECK 约束表达式
+	Comment         string
+	AutoCreateTime  AutoTime // set on insert when zero
+	AutoUpdateTime  AutoTime // set on insert when zero and on every update
+	IsVersion       bool     // optimistic lock counter, qsy:"version"
+	StructIndex     []int    // 字段在模型中的索引路径，嵌入结构体的字段有多级
+
+	softDelete bool // tagged softdelete
 }
 
 type Schema struct {
 	Model       interface{}
 	Name        string
+	Table       string // 表名，由命名策略生成
 	Fields      []*Field
 	FieldNames  []string          // 存储数据库列名
-	FieldMap    map[string]*Field // 数据库列名到Field的映射
+	FieldMap    map[string]*Field // Go字段名到Field的映射
 	DbFieldToGo map[string]string // 数据库列名到Go字段名的映射
 	Dialect     qsydialect.Dialect
 	Clause      *qsyclause.Builder
+
+	// SoftDeleteField is the DeletedAt field of a soft-deletable type (see
+	// SoftDeletable), or the field tagged softdelete; nil when the model is
+	// hard-deleted
+	SoftDeleteField *Field
+
+	// Indexes are the indexes declared by index and uniqueIndex tags
+	Indexes []*Index
 }
 
 func (s *Schema) GetField(name string) *Field {
 	return s.FieldMap[name]
 }
 
+// AutoIncrementField returns the auto-increment primary key field, or nil
+func (s *Schema) AutoIncrementField() *Field {
+	for _, field := range s.Fields {
+		if field.IsPrimaryKey && field.IsAutoIncrement {
+			return field
+		}
+	}
+	return nil
+}
+
+// VersionField returns the optimistic lock field tagged version, or nil
+func (s *Schema) VersionField() *Field {
+	for _, field := range s.Fields {
+		if field.IsVersion {
+			return field
+		}
+	}
+	return nil
+}
+
+// PrimaryFields returns the primary key fields in declaration order
+func (s *Schema) PrimaryFields() []*Field {
+	var fields []*Field
+	for _, field := range s.Fields {
+		if field.IsPrimaryKey {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 // parse tag
 // "primarykey;not null" is a tag
 func (s *Schema) parseTag(tag string) map[string]string {
+	return ParseTag(tag)
+}
+
+// ParseTag splits a qsy tag such as "name:user_id;primarykey" into its settings
+func ParseTag(tag string) map[string]string {
 	result := make(map[string]string)
 	if tag == "" {
 		return result
 	}
 
 	for _, field := range strings.Split(tag, ";") {
+		field = strings.TrimSpace(field)
 		if field == "" {
 			continue
 		}
@@ -66,11 +147,28 @@ func (s *Schema) parseTag(tag string) map[string]string {
 	return result
 }
 
+// Tabler is implemented by models that name their own table,
+// overriding the naming strategy
+type Tabler interface {
+	TableName() string
+}
+
+// Parse builds the schema of dest with the default NamingStrategy
 func Parse(dest interface{}, d qsydialect.Dialect) *Schema {
+	return ParseWithNamer(dest, d, nil)
+}
+
+// ParseWithNamer builds the schema of dest, naming the table and the
+// untagged columns with namer; a nil namer is the default NamingStrategy
+func ParseWithNamer(dest interface{}, d qsydialect.Dialect, namer Namer) *Schema {
+	if namer == nil {
+		namer = NamingStrategy{}
+	}
 	modelType := reflect.Indirect(reflect.ValueOf(dest)).Type()
 	schema := &Schema{
 		Model:       dest,
 		Name:        modelType.Name(),
+		Table:       namer.TableName(modelType.Name()),
 		FieldMap:    make(map[string]*Field),
 		DbFieldToGo: make(map[string]string),
 		Dialect:     d,
@@ -78,61 +176,256 @@ func Parse(dest interface{}, d qsydialect.Dialect) *Schema {
 	if d == nil {
 		panic("qsydialect: nil Dialect")
 	}
-	for i := 0; i < modelType.NumField(); i++ {
-		p := modelType.Field(i)
-		if !p.IsExported() {
+	if tabler, ok := reflect.New(modelType).Interface().(Tabler); ok {
+		schema.Table = tabler.TableName()
+	}
+	schema.parseFields(modelType, nil, "", "", namer)
+
+	for _, field := range schema.Fields {
+		// 列名和Go字段名都能找到对应的Go字段
+		schema.DbFieldToGo[field.DBName] = field.Name
+		schema.DbFieldToGo[field.Name] = field.Name
+		schema.FieldNames = append(schema.FieldNames, field.DBName)
+
+		// 没有打标签时，类型合适的 DeletedAt 字段默认用于软删除；
+		// 其它类型（如无法表示未删除的 time.Time）作为普通列
+		if field.softDelete || field.Name == "DeletedAt" && schema.SoftDeleteField == nil && SoftDeletable(field.GoType) {
+			schema.SoftDeleteField = field
+		}
+	}
+	schema.parseIndexes()
+	return schema
+}
+
+// parseFields adds the columns of struct type t, found at index path index
+// of the model, flattening anonymous structs and fields tagged embedded.
+// Column names get prefix, Go names get namePrefix ("Address." for the
+// fields of an embedded Address field).
+func (s *Schema) parseFields(t reflect.Type, index []int, prefix, namePrefix string, namer Namer) {
+	for i := 0; i < t.NumField(); i++ {
+		p := t.Field(i)
+		tags := s.parseTag(p.Tag.Get("qsy"))
+		path := append(index[:len(index):len(index)], i)
+
+		if embedded := embeddedStruct(p, tags); embedded != nil {
+			// 匿名结构体的字段提升到外层，具名的嵌入字段带上字段名
+			names := namePrefix
+			if !p.Anonymous {
+				names += p.Name + "."
+			}
+			s.parseFields(embedded, path, prefix+tags["embeddedPrefix"], names, namer)
+			continue
+		}
+		if !p.IsExported() || !ast.IsExported(p.Name) {
 			continue
 		}
-		if !p.Anonymous && ast.IsExported(p.Name) {
-			// 使用原始Go字段名作为Schema.Field.Name，这样插入时使用这个作为数据库列名
-			// 确保大小写一致性
-			field := &Field{
-				Name: p.Name, // 使用结构体原始字段名
-				Type: d.DataTypeOf(reflect.Indirect(reflect.New(p.Type))),
+
+		// Field.Name 保留Go字段名，生成的SQL统一使用 Field.DBName
+		field := &Field{
+			Name:        namePrefix + p.Name,
+			DBName:      prefix + namer.ColumnName(p.Name),
+			Type:        s.Dialect.DataTypeOf(reflect.Indirect(reflect.New(p.Type))),
+			GoType:      p.Type,
+			Tag:         p.Tag.Get("qsy"),
+			StructIndex: path,
+		}
+
+		// name 标签指定真实的列名，优先于命名策略
+		if name, ok := tags["name"]; ok && name != "" {
+			field.DBName = prefix + name
+		}
+		if _, ok := tags["primarykey"]; ok {
+			field.IsPrimaryKey = true
+		}
+		if _, ok := tags["autoincrement"]; ok {
+			field.IsAutoIncrement = true
+		}
+		if _, ok := tags["unique"]; ok {
+			field.Unique = true
+		}
+		if _, ok := tags["index"]; ok {
+			field.Index = true
+		}
+		if _, ok := tags["uniqueIndex"]; ok {
+			field.Index = true
+		}
+		if _, ok := tags["not null"]; ok {
+			field.NotNull = true
+		}
+		if _, ok := tags["notnull"]; ok {
+			field.NotNull = true
+		}
+		if value, ok := tags["default"]; ok {
+			field.HasDefault = true
+			field.Default = defaultValue(p.Type, value)
+		}
+		if typ, ok := tags["type"]; ok && typ != "" {
+			field.Type = typ
+		}
+		field.Size = s.intTag(p, tags, "size")
+		field.Precision = s.intTag(p, tags, "precision")
+		field.Scale = s.intTag(p, tags, "scale")
+		field.Check = tags["check"]
+		field.Comment = tags["comment"]
+		if unit, ok := tags["autoCreateTime"]; ok {
+			field.AutoCreateTime = autoTimeOf(s.Name, p, unit)
+		}
+		if unit, ok := tags["autoUpdateTime"]; ok {
+			field.AutoUpdateTime = autoTimeOf(s.Name, p, unit)
+		}
+		if _, ok := tags["version"]; ok {
+			if !IsInteger(p.Type) {
+				panic(fmt.Sprintf("qsyschema: version field %s.%s must be an integer", s.Name, p.Name))
+			}
+			field.IsVersion = true
+		}
+		if _, ok := tags["softdelete"]; ok {
+			if !SoftDeletable(p.Type) {
+				panic(fmt.Sprintf("qsyschema: softdelete field %s.%s must be *time.Time, sql.NullTime or an integer", s.Name, p.Name))
 			}
+			field.softDelete = true
+		}
+
+		// 没有打标签时，类型合适的 CreatedAt/UpdatedAt 字段自动维护，
+		// 字符串等其它类型的同名字段保持原样
+		if p.Name == "CreatedAt" && field.AutoCreateTime == AutoTimeNone && autoTimeType(p.Type) {
+			field.AutoCreateTime = autoTimeOf(s.Name, p, "")
+		}
+		if p.Name == "UpdatedAt" && field.AutoUpdateTime == AutoTimeNone && autoTimeType(p.Type) {
+			field.AutoUpdateTime = autoTimeOf(s.Name, p, "")
+		}
+
+		s.addField(field)
+	}
+}
+
+// intTag reads a numeric tag such as size:255, 0 when absent
+func (s *Schema) intTag(p reflect.StructField, tags map[string]string, key string) int {
+	value, ok := tags[key]
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		panic(fmt.Sprintf("qsyschema: invalid %s %q on %s.%s", key, value, s.Name, p.Name))
+	}
+	return n
+}
+
+// defaultValue quotes the default of a string field unless it is already
+// quoted, NULL or a parenthesized expression
+func defaultValue(t reflect.Type, value string) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String || strings.HasPrefix(value, "'") ||
+		strings.HasPrefix(value, "(") || strings.EqualFold(value, "NULL") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
 
-			if v, ok := p.Tag.Lookup("qsy"); ok {
-				field.Tag = v
-				tags := schema.parseTag(v)
-
-				// name不再覆盖默认字段名，但建立Go字段名到数据库列名的双向映射
-				// 这样在ORM内部使用Field.Name（Go字段名）时，数据库操作也能正常工作
-				if name, ok := tags["name"]; ok && name != "" {
-					// 在DbFieldToGo中保存映射，但Field.Name仍使用Go字段名
-					schema.DbFieldToGo[name] = p.Name   // 数据库列名 -> Go字段名
-					schema.DbFieldToGo[p.Name] = p.Name // Go字段名 -> Go字段名（自映射，保证查找一致）
-				} else {
-					// 没有name标签，Go字段名映射到自身
-					schema.DbFieldToGo[p.Name] = p.Name
-				}
-
-				if _, ok := tags["primarykey"]; ok {
-					field.IsPrimaryKey = true
-				}
-				if _, ok := tags["autoincrement"]; ok {
-					field.IsAutoIncrement = true
-				}
-				if _, ok := tags["unique"]; ok {
-					field.Unique = true
-				}
-				if _, ok := tags["index"]; ok {
-					field.Index = true
-				}
-			} else {
-				// 如果没有qsy标签，Go字段名映射到自身
-				schema.DbFieldToGo[p.Name] = p.Name
+// ColumnDefinition returns the column of field for the dialect DDL
+func (f *Field) ColumnDefinition() qsydialect.Column {
+	return qsydialect.Column{
+		Name:          f.DBName,
+		Type:          f.Type,
+		Size:          f.Size,
+		Precision:     f.Precision,
+		Scale:         f.Scale,
+		PrimaryKey:    f.IsPrimaryKey,
+		AutoIncrement: f.IsAutoIncrement,
+		NotNull:       f.NotNull,
+		Unique:        f.Unique,
+		HasDefault:    f.HasDefault,
+		Default:       f.Default,
+		Check:         f.Check,
+		Comment:       f.Comment,
+	}
+}
+
+// addField appends field; as in Go, a field nested less deeply shadows one
+// of the same name from an embedded struct
+func (s *Schema) addField(field *Field) {
+	if existing, ok := s.FieldMap[field.Name]; ok {
+		if len(existing.StructIndex) <= len(field.StructIndex) {
+			return
+		}
+		for i, f := range s.Fields {
+			if f == existing {
+				s.Fields = append(s.Fields[:i], s.Fields[i+1:]...)
+				break
 			}
+		}
+	}
+	s.Fields = append(s.Fields, field)
+	s.FieldMap[field.Name] = field
+}
 
-			schema.Fields = append(schema.Fields, field)
-			schema.FieldNames = append(schema.FieldNames, field.Name)
-			schema.FieldMap[field.Name] = field
+// embeddedStruct returns the struct type whose fields p contributes to the
+// model: an anonymous struct, or a struct field tagged embedded
+func embeddedStruct(p reflect.StructField, tags map[string]string) reflect.Type {
+	_, tagged := tags["embedded"]
+	if !p.Anonymous && !tagged {
+		return nil
+	}
+	t := p.Type
+	if t.Kind() == reflect.Ptr {
+		// 未导出的指针无法分配，字段也就无法写入
+		if !p.IsExported() {
+			return nil
 		}
+		t = t.Elem()
 	}
-	return schema
+	if t.Kind() != reflect.Struct || t == timeType || t == nullTimeType {
+		return nil
+	}
+	return t
+}
+
+// autoTimeType reports whether a field of type t can hold an automatic timestamp
+func autoTimeType(t reflect.Type) bool {
+	return t == timeType || (t.Kind() == reflect.Ptr && t.Elem() == timeType) || IsInteger(t)
+}
+
+// autoTimeOf returns how field p stores its automatic timestamp; unit is the
+// tag value, "milli" selecting milliseconds for integer fields
+func autoTimeOf(model string, p reflect.StructField, unit string) AutoTime {
+	switch {
+	case !autoTimeType(p.Type):
+		panic(fmt.Sprintf("qsyschema: timestamp field %s.%s must be time.Time, *time.Time or an integer", model, p.Name))
+	case !IsInteger(p.Type):
+		return AutoTimeValue
+	case unit == "milli":
+		return AutoTimeUnixMilli
+	}
+	return AutoTimeUnix
+}
+
+// IsInteger reports whether t is a signed or unsigned integer type
+func IsInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// SoftDeletable reports whether a field of type t can record soft deletion:
+// a nullable time (*time.Time or sql.NullTime, NULL while the row is alive)
+// or an integer holding unix seconds (0 while alive)
+func SoftDeletable(t reflect.Type) bool {
+	if IsInteger(t) {
+		return true
+	}
+	return (t.Kind() == reflect.Ptr && t.Elem() == timeType) || t == nullTimeType
 }
 
 // GetTableName 返回结构体对应的表名，默认使用结构体名称的小写形式
 func (s *Schema) GetTableName() string {
-	// 保持使用小写表名，与之前一致
-	return strings.ToLower(s.Name)
+	if s.Table == "" {
+		return strings.ToLower(s.Name)
+	}
+	return s.Table
 }
@@ -2,7 +2,9 @@ package qsyschema
 
 import (
 	"qsyorm/qsydialect"
+	"reflect"
 	"testing"
+	"time"
 )
 
 type User struct {
@@ -29,3 +31,160 @@ func TestParse(t *testing.T) {
 	}
 	t.Logf("Age field type: %s", ageField.Type)
 }
+
+func TestParseSoftDelete(t *testing.T) {
+	type Post struct {
+		ID        int `qsy:"primarykey"`
+		DeletedAt *time.Time
+	}
+	type Tagged struct {
+		ID      int   `qsy:"primarykey"`
+		Removed int64 `qsy:"softdelete"`
+	}
+	type Plain struct {
+		ID        int `qsy:"primarykey"`
+		DeletedAt string
+	}
+
+	if field := Parse(&Post{}, testDialect).SoftDeleteField; field == nil || field.Name != "DeletedAt" || field.Type != "DATETIME" {
+		t.Fatalf("DeletedAt not detected: %+v", field)
+	}
+	if field := Parse(&Tagged{}, testDialect).SoftDeleteField; field == nil || field.Name != "Removed" {
+		t.Fatalf("softdelete tag not detected: %+v", field)
+	}
+	if field := Parse(&Plain{}, testDialect).SoftDeleteField; field != nil {
+		t.Fatalf("string DeletedAt should not soft delete: %+v", field)
+	}
+
+	// time.Time cannot be NULL for alive rows, so it stays a plain column
+	type Value struct {
+		ID        int `qsy:"primarykey"`
+		DeletedAt time.Time
+	}
+	schema := Parse(&Value{}, testDialect)
+	if schema.SoftDeleteField != nil || schema.GetField("DeletedAt") == nil {
+		t.Fatalf("time.Time DeletedAt should be a plain column: %+v", schema.SoftDeleteField)
+	}
+}
+
+func TestParseAutoTime(t *testing.T) {
+	type Event struct {
+		ID        int `qsy:"primarykey"`
+		CreatedAt time.Time
+		UpdatedAt int64
+		Seen      int64 `qsy:"autoUpdateTime:milli"`
+	}
+	type Legacy struct {
+		CreatedAt string
+		UpdatedAt string
+	}
+
+	schema := Parse(&Event{}, testDialect)
+	if schema.FieldMap["CreatedAt"].AutoCreateTime != AutoTimeValue {
+		t.Fatal("CreatedAt not detected")
+	}
+	if schema.FieldMap["UpdatedAt"].AutoUpdateTime != AutoTimeUnix {
+		t.Fatal("integer UpdatedAt should store unix seconds")
+	}
+	if schema.FieldMap["Seen"].AutoUpdateTime != AutoTimeUnixMilli {
+		t.Fatal("autoUpdateTime:milli not detected")
+	}
+	for _, field := range Parse(&Legacy{}, testDialect).Fields {
+		if field.AutoCreateTime != AutoTimeNone || field.AutoUpdateTime != AutoTimeNone {
+			t.Fatalf("string field %s should not be managed", field.Name)
+		}
+	}
+}
+
+type BaseModel struct {
+	ID        int `qsy:"primarykey;autoincrement"`
+	CreatedAt time.Time
+}
+
+type Address struct {
+	City string
+	Zip  string `qsy:"name:postcode"`
+}
+
+func TestParseEmbedded(t *testing.T) {
+	type Customer struct {
+		BaseModel
+		Name    string
+		Home    Address  `qsy:"embedded;embeddedPrefix:addr_"`
+		Work    *Address `qsy:"embedded;embeddedPrefix:work_"`
+		ID      int64    `qsy:"primarykey"` // 覆盖 BaseModel.ID
+		address Address
+	}
+
+	schema := Parse(&Customer{}, testDialect)
+	want := []string{"CreatedAt", "Name", "addr_City", "addr_postcode", "work_City", "work_postcode", "ID"}
+	if len(schema.FieldNames) != len(want) {
+		t.Fatalf("unexpected columns %v", schema.FieldNames)
+	}
+	for i, name := range want {
+		if schema.FieldNames[i] != name {
+			t.Fatalf("unexpected columns %v", schema.FieldNames)
+		}
+	}
+	if field := schema.FieldMap["Home.City"]; field == nil || field.DBName != "addr_City" || len(field.StructIndex) != 2 {
+		t.Fatalf("nested field not parsed: %+v", field)
+	}
+	if field := schema.FieldMap["CreatedAt"]; field.AutoCreateTime != AutoTimeValue || len(field.StructIndex) != 2 {
+		t.Fatalf("embedded CreatedAt not detected: %+v", field)
+	}
+	if field := schema.FieldMap["ID"]; field.GoType.Kind() != reflect.Int64 || len(field.StructIndex) != 1 {
+		t.Fatalf("outer ID should shadow the embedded one: %+v", field)
+	}
+	if schema.DbFieldToGo["addr_postcode"] != "Home.Zip" {
+		t.Fatal("prefixed column not mapped to its field path")
+	}
+}
+
+func TestParseColumnTags(t *testing.T) {
+	type Product struct {
+		ID     int64   `qsy:"primarykey;autoincrement"`
+		Name   string  `qsy:"not null;size:100;default:unnamed;comment:display name"`
+		Code   string  `qsy:"type:CHAR(8); notnull"`
+		Price  float64 `qsy:"precision:10;scale:2;check:Price >= 0"`
+		Status string  `qsy:"default:'draft'"`
+	}
+
+	schema := Parse(&Product{}, testDialect)
+	name := schema.GetField("Name")
+	if !name.NotNull || name.Size != 100 || !name.HasDefault || name.Default != "'unnamed'" || name.Comment != "display name" {
+		t.Fatalf("column tags not parsed: %+v", name)
+	}
+	if code := schema.GetField("Code"); code.Type != "CHAR(8)" || !code.NotNull {
+		t.Fatalf("type tag not parsed: %+v", code)
+	}
+	price := schema.GetField("Price")
+	if price.Precision != 10 || price.Scale != 2 || price.Check != "Price >= 0" {
+		t.Fatalf("decimal tags not parsed: %+v", price)
+	}
+	if status := schema.GetField("Status"); status.Default != "'draft'" {
+		t.Fatalf("quoted default changed: %q", status.Default)
+	}
+
+	want := map[string]string{
+		"ID":     `"ID" INTEGER PRIMARY KEY AUTOINCREMENT`,
+		"Name":   `"Name" VARCHAR(100) NOT NULL DEFAULT 'unnamed' /* display name */`,
+		"Code":   `"Code" CHAR(8) NOT NULL`,
+		"Price":  `"Price" DECIMAL(10,2) CHECK (Price >= 0)`,
+		"Status": `"Status" TEXT DEFAULT 'draft'`,
+	}
+	for field, ddl := range want {
+		if got := testDialect.ColumnDefinition(schema.GetField(field).ColumnDefinition()); got != ddl {
+			t.Errorf("expected %s DDL %q, got %q", field, ddl, got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected invalid size to panic")
+		}
+	}()
+	type Bad struct {
+		Name string `qsy:"size:big"`
+	}
+	Parse(&Bad{}, testDialect)
+}
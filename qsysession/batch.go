@@ -0,0 +1,272 @@
+package qsysession
+
+import (
+	"errors"
+	"fmt"
+	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
+	"reflect"
+)
+
+// TransactionPerBatch makes the next FindInBatches run each callback in its own transaction
+func (s *Session) TransactionPerBatch() *Session {
+	s.statement.batchTx = true
+	return s
+}
+
+// FindInBatches loads the matching records batchSize at a time into dest
+// (a pointer to slice) and calls fc after each batch, numbering batches
+// from 1. Batches are paged by primary key (WHERE pk > last ORDER BY pk)
+// rather than OFFSET, so every page costs the same. Returning an error
+// from fc stops the loop and the error is returned. Batched records are
+// not tracked for dirty checking (see Changes), so the session does not
+// keep a copy of every row read. Chained Order is replaced by the primary
+// key, which is always loaded even if omitted; Limit and Offset cannot be
+// combined with paging and are refused.
+func (s *Session) FindInBatches(dest interface{}, batchSize int, fc func(tx *Session, batch int) error) error {
+	base := s.statement
+	defer s.resetStatement()
+	if s.Schema == nil {
+		return errors.New("schema is nil")
+	}
+	if batchSize <= 0 {
+		return fmt.Errorf("invalid batch size %d", batchSize)
+	}
+	// 每一页都会重新应用 LIMIT/OFFSET，无法和按主键翻页组合
+	if base.hasLimit || base.hasOffset {
+		return errors.New("FindInBatches does not support Limit or Offset, filter with Where instead")
+	}
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Slice {
+		return errors.New("dest must be a pointer to slice")
+	}
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) != 1 {
+		return fmt.Errorf("FindInBatches needs a single primary key, model %s has %d", s.Schema.Name, len(primaries))
+	}
+	pk := primaries[0]
+	schema := s.Schema
+
+	// 按主键翻页，Select 中必须包含主键，Omit 中不能排除主键
+	if len(base.selects) > 0 && !containsString(base.selects, pk.Name) && !containsString(base.selects, pk.DBName) {
+		base.selects = append(append([]string(nil), base.selects...), pk.Name)
+	}
+	if len(base.omits) > 0 {
+		omits := make([]string, 0, len(base.omits))
+		for _, name := range base.omits {
+			if name != pk.Name && name != pk.DBName {
+				omits = append(omits, name)
+			}
+		}
+		base.omits = omits
+	}
+	base.orders = nil
+	// 分批读取的记录不保存快照，避免会话持有整张表的副本
+	base.untracked = true
+
+	var lastPK interface{}
+	for batch := 1; ; batch++ {
+		// 回调中可能使用同一个会话执行其它操作，每批都恢复查询状态
+		s.Schema = schema
+		s.statement = base
+		s.statement.where = append([]qsyclause.Expression(nil), base.where...)
+		if lastPK != nil {
+			s.Where(qsyclause.Gt{Column: pk.DBName, Value: lastPK})
+		}
+		s.Order(s.dialect.Quote(pk.DBName)).Limit(batchSize)
+
+		destValue.Elem().Set(reflect.MakeSlice(destValue.Elem().Type(), 0, batchSize))
+		if err := s.Find(dest); err != nil {
+			return err
+		}
+		rows := destValue.Elem().Len()
+		if rows == 0 {
+			return nil
+		}
+		lastElem := reflect.Indirect(destValue.Elem().Index(rows - 1))
+		next := s.fieldValue(lastElem, pk).Interface()
+		// 主键没有前进时下一页仍是同一批记录，继续翻页会死循环
+		if lastPK != nil && reflect.DeepEqual(next, lastPK) {
+			return fmt.Errorf("FindInBatches: primary key %s did not advance past %v", pk.Name, lastPK)
+		}
+		lastPK = next
+
+		var err error
+		if base.batchTx && s.tx == nil {
+			err = s.Transaction(func(tx *Session) error {
+				return fc(tx, batch)
+			})
+		} else {
+			err = fc(s, batch)
+		}
+		if err != nil {
+			return err
+		}
+		if rows < batchSize {
+			return nil
+		}
+	}
+}
+
+// UpdateBatch writes records that each carry their own values with one
+// statement per chunk instead of one Update per record:
+//
+//	UPDATE t SET col = CASE pk WHEN ? THEN ? ... END, ... WHERE pk IN (...)
+//
+// values is a slice of structs or struct pointers of the session model. cols
+// names the columns to write and defaults to every non-key column, narrowed
+// by Select/Omit; UpdatedAt columns are written unless omitted. Chained
+// conditions are added to the WHERE clause.
+// BeforeUpdate and AfterUpdate run for every record. Chunks are sized to
+// stay under the bind-var limit and run in one transaction; the result
+// holds the rows affected by each chunk. Snapshots of tracked records are
+// refreshed with the written values (see Changes). Models with a version
+// field are refused, since the CASE statement cannot check each record's
+// version.
+func (s *Session) UpdateBatch(values interface{}, cols ...string) ([]int64, error) {
+	defer s.resetStatement()
+	if s.Schema == nil {
+		return nil, errors.New("schema is nil")
+	}
+	if err := s.applyConds(nil); err != nil {
+		return nil, err
+	}
+	if kind := reflect.Indirect(reflect.ValueOf(values)).Kind(); kind != reflect.Slice && kind != reflect.Array {
+		return nil, errors.New("values must be a slice")
+	}
+	records := flattenValues([]interface{}{values})
+	if len(records) == 0 {
+		return nil, nil
+	}
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) != 1 {
+		return nil, fmt.Errorf("UpdateBatch needs a single primary key, model %s has %d", s.Schema.Name, len(primaries))
+	}
+	pk := primaries[0]
+	// CASE 语句无法逐行校验版本号，带版本号的模型使用 Update 或 Save
+	if s.Schema.VersionField() != nil {
+		return nil, fmt.Errorf("UpdateBatch does not support versioned model %s, use Update or Save", s.Schema.Name)
+	}
+
+	if len(cols) > 0 {
+		s.statement.selects = cols
+	}
+	fields, err := s.updateFields()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]*qsyschema.Field, 0, len(fields))
+	for _, field := range fields {
+		if !field.IsPrimaryKey {
+			columns = append(columns, field)
+		}
+	}
+	// 更新时间不受列清单限制，除非被 Omit 排除
+	for _, field := range s.updateTimeFields() {
+		if !containsField(columns, field) {
+			columns = append(columns, field)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("no columns to update")
+	}
+
+	// 调用 BeforeUpdate 钩子，之后再读取字段值
+	for _, record := range records {
+		if err := s.CallBeforeUpdate(record); err != nil {
+			return nil, err
+		}
+	}
+	now := s.now()
+	keys := make([]interface{}, len(records))
+	rows := make([][]interface{}, len(records))
+	reflectValues := make([]reflect.Value, len(records))
+	for i, record := range records {
+		reflectValue, err := s.modelValue(record)
+		if err != nil {
+			return nil, err
+		}
+		reflectValues[i] = reflectValue
+		keys[i] = s.fieldValue(reflectValue, pk).Interface()
+		rows[i] = make([]interface{}, len(columns))
+		for j, field := range columns {
+			value := s.fieldValue(reflectValue, field)
+			if field.AutoUpdateTime != qsyschema.AutoTimeNone {
+				rows[i][j] = stampTime(value, field.AutoUpdateTime, now)
+			} else {
+				rows[i][j] = value.Interface()
+			}
+		}
+	}
+
+	// 每条记录占用 2*列数 + 1 个绑定变量
+	where := s.whereExprs()
+	chunkSize := s.chunkSize(2*len(columns) + 1)
+
+	var affected []int64
+	update := func(s *Session) error {
+		for start := 0; start < len(records); start += chunkSize {
+			end := start + chunkSize
+			if end > len(records) {
+				end = len(records)
+			}
+
+			assignments := make(qsyclause.Assignments, len(columns))
+			for j, field := range columns {
+				whens := make([]qsyclause.When, 0, end-start)
+				for i := start; i < end; i++ {
+					whens = append(whens, qsyclause.When{Value: keys[i], Then: rows[i][j]})
+				}
+				assignments[j] = qsyclause.Assignment{
+					Column: field.DBName,
+					Value:  qsyclause.Case{Column: pk.DBName, Whens: whens},
+				}
+			}
+			conditions := append([]qsyclause.Expression{qsyclause.In{Column: pk.DBName, Values: keys[start:end]}}, where...)
+
+			n, err := s.execUpdate(assignments, conditions)
+			if err != nil {
+				return err
+			}
+			affected = append(affected, n)
+
+			// 分块中的每一行都被更新时刷新快照，否则无法得知哪些行被条件排除，丢弃它们的快照
+			for i := start; i < end; i++ {
+				if n != int64(end-start) {
+					s.forgetSnapshot(reflectValues[i])
+					continue
+				}
+				written := make(qsyclause.Assignments, len(columns))
+				for j, field := range columns {
+					written[j] = qsyclause.Assignment{Column: field.DBName, Value: rows[i][j]}
+				}
+				s.refreshSnapshot(reflectValues[i], written)
+			}
+
+			// 调用 AfterUpdate 钩子
+			for _, record := range records[start:end] {
+				if err := s.CallAfterUpdate(record); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// 多个分块放在同一个事务中，已经处于事务中时直接复用
+	if len(records) > chunkSize && s.tx == nil {
+		err = s.Transaction(update)
+	} else {
+		err = update(s)
+	}
+	return affected, err
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
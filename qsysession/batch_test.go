@@ -0,0 +1,152 @@
+package qsysession_test
+
+import (
+	"errors"
+	"fmt"
+	"qsyorm/qsysession"
+	"testing"
+)
+
+func TestFindInBatches(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	users := make([]TestUser, 25)
+	for i := range users {
+		users[i] = TestUser{Name: "user", Age: i}
+	}
+	if _, err := s.Insert(users); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	var batch []TestUser
+	var sizes []int
+	err := s.Where("Age >= ?", 3).FindInBatches(&batch, 10, func(tx *qsysession.Session, n int) error {
+		sizes = append(sizes, len(batch))
+		for _, u := range batch {
+			// 回调中使用同一个会话更新记录
+			if _, err := tx.Where("ID = ?", u.ID).Update(&TestUser{ID: u.ID, Name: "done", Age: u.Age}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("分批处理失败:", err)
+	}
+	if len(sizes) != 3 || sizes[0] != 10 || sizes[1] != 10 || sizes[2] != 2 {
+		t.Fatalf("分批大小错误: %v", sizes)
+	}
+	if n, _ := s.Count("Name = ?", "done"); n != 22 {
+		t.Fatalf("期望更新22条记录，实际%d条", n)
+	}
+
+	// 返回错误时停止，并回滚当前批次的事务
+	stop := errors.New("stop")
+	calls := 0
+	err = s.TransactionPerBatch().FindInBatches(&batch, 10, func(tx *qsysession.Session, n int) error {
+		calls++
+		if _, err := tx.Where("ID = ?", batch[0].ID).Delete(); err != nil {
+			return err
+		}
+		if n == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || calls != 2 {
+		t.Fatalf("期望在第2批停止，实际 %d 批, %v", calls, err)
+	}
+	if n, _ := s.Count(); n != 24 {
+		t.Fatalf("期望第2批回滚后剩余24条记录，实际%d条", n)
+	}
+
+	if err := s.FindInBatches(&batch, 0, nil); err == nil {
+		t.Fatal("期望非法批大小报错")
+	}
+
+	// Offset/Limit 会在每一页重复生效，直接拒绝
+	if err := s.Offset(1).FindInBatches(&batch, 10, nil); err == nil {
+		t.Fatal("期望 Offset 报错")
+	}
+	if err := s.Limit(5).FindInBatches(&batch, 10, nil); err == nil {
+		t.Fatal("期望 Limit 报错")
+	}
+	var all []TestUser
+	if err := s.Find(&all); err != nil || len(all) != 24 {
+		t.Fatalf("拒绝后链式状态未重置: %d, %v", len(all), err)
+	}
+
+	// Omit 主键时仍需读取主键翻页，否则会一直读取第一页
+	pages, rows := 0, 0
+	err = s.Omit("ID").FindInBatches(&batch, 10, func(tx *qsysession.Session, n int) error {
+		pages++
+		rows += len(batch)
+		if batch[0].ID == 0 {
+			t.Fatal("Omit 主键后主键未读取")
+		}
+		return nil
+	})
+	if err != nil || pages != 3 || rows != 24 {
+		t.Fatalf("Omit 主键分批错误: %d 批 %d 条, %v", pages, rows, err)
+	}
+}
+
+func (u *BatchUser) BeforeUpdate() error {
+	u.hooks++
+	return nil
+}
+
+func (u *BatchUser) AfterUpdate() error {
+	u.hooks++
+	return nil
+}
+
+func TestUpdateBatch(t *testing.T) {
+	s := newTestSession(t, &BatchUser{})
+	users := make([]BatchUser, 700)
+	for i := range users {
+		users[i] = BatchUser{Name: fmt.Sprintf("user%d", i), Age: i}
+	}
+	if _, err := s.Insert(users); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	for i := range users {
+		users[i].hooks = 0
+		users[i].Age = i * 2
+		users[i].Name = "ignored"
+	}
+	// 只写 Age：每条记录 3 个绑定变量，999/3 = 333 条一块
+	affected, err := s.UpdateBatch(users, "Age")
+	if err != nil {
+		t.Fatal("批量更新失败:", err)
+	}
+	if len(affected) != 3 || affected[0] != 333 || affected[1] != 333 || affected[2] != 34 {
+		t.Fatalf("分块影响行数错误: %v", affected)
+	}
+	for i := range users {
+		if users[i].hooks != 2 {
+			t.Fatalf("第%d条记录钩子调用次数为%d", i, users[i].hooks)
+		}
+	}
+
+	var stored []BatchUser
+	if err := s.Order("ID").Find(&stored); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	for i, u := range stored {
+		if u.Age != i*2 || u.Name != fmt.Sprintf("user%d", i) {
+			t.Fatalf("第%d条记录更新结果错误: %+v", i, u)
+		}
+	}
+
+	// 链式条件与主键条件一起生效
+	updates := []*BatchUser{{ID: 1, Name: "a", Age: 1}, {ID: 2, Name: "b", Age: 2}}
+	affected, err = s.Where("Age > ?", 0).UpdateBatch(updates)
+	if err != nil || len(affected) != 1 || affected[0] != 1 {
+		t.Fatalf("带条件批量更新错误: %v, %v", affected, err)
+	}
+
+	if _, err := s.UpdateBatch(BatchUser{}); err == nil {
+		t.Fatal("期望非切片参数报错")
+	}
+}
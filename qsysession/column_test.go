@@ -0,0 +1,157 @@
+package qsysession_test
+
+import (
+	"database/sql"
+	"qsyorm/qsysession"
+	"testing"
+	"time"
+)
+
+type Wallet struct {
+	ID      int64   `qsy:"primarykey;autoincrement"`
+	Email   string  `qsy:"not null;unique;size:120"`
+	Role    string  `qsy:"not null;default:member"`
+	Balance float64 `qsy:"precision:12;scale:2;default:0;check:Balance >= 0"`
+	Note    *string `qsy:"comment:free text"`
+}
+
+func TestCreateTableColumnTags(t *testing.T) {
+	s := newTestSession(t, &Wallet{})
+
+	var ddl string
+	if err := s.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'wallet'").QueryRow().Scan(&ddl); err != nil {
+		t.Fatal("读取建表语句失败:", err)
+	}
+	want := `CREATE TABLE "wallet" ("ID" INTEGER PRIMARY KEY AUTOINCREMENT, "Email" VARCHAR(120) NOT NULL UNIQUE, ` +
+		`"Role" TEXT NOT NULL DEFAULT 'member', "Balance" DECIMAL(12,2) DEFAULT 0 CHECK (Balance >= 0), "Note" TEXT /* free text */)`
+	if ddl != want {
+		t.Fatalf("建表语句错误:\n%s\n期望:\n%s", ddl, want)
+	}
+
+	// 默认值和约束由数据库生效
+	if _, err := s.Raw("INSERT INTO wallet (Email) VALUES (?)", "a@example.com").Exec(); err != nil {
+		t.Fatal("插入记录失败:", err)
+	}
+	var wallet Wallet
+	if err := s.Where("Email = ?", "a@example.com").First(&wallet); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	if wallet.Role != "member" || wallet.Balance != 0 || wallet.Note != nil {
+		t.Fatalf("默认值错误: %+v", wallet)
+	}
+	if _, err := s.Raw("INSERT INTO wallet (Email, Balance) VALUES (?, ?)", "b@example.com", -1).Exec(); err == nil {
+		t.Fatal("期望 CHECK 约束拒绝负数余额")
+	}
+	if _, err := s.Raw("INSERT INTO wallet (Role) VALUES (?)", "admin").Exec(); err == nil {
+		t.Fatal("期望 NOT NULL 约束拒绝空邮箱")
+	}
+
+	// 通过 ORM 插入时非指针字段的零值照常写入，不会被列默认值替换
+	wallets := []Wallet{{Email: "c@example.com"}, {Email: "d@example.com", Role: "admin", Balance: 5}}
+	if _, err := s.Insert(wallets); err != nil {
+		t.Fatal("插入记录失败:", err)
+	}
+	if wallets[0].ID == 0 || wallets[1].ID == 0 {
+		t.Fatalf("插入后 ID 未回填: %+v", wallets)
+	}
+	var c, d Wallet
+	if err := s.Get(&c, wallets[0].ID); err != nil || c.Email != "c@example.com" || c.Role != "" {
+		t.Fatalf("零值应照常写入: %+v, %v", c, err)
+	}
+	if err := s.Get(&d, wallets[1].ID); err != nil || d.Email != "d@example.com" || d.Role != "admin" || d.Balance != 5 {
+		t.Fatalf("给出的值被默认值覆盖: %+v, %v", d, err)
+	}
+}
+
+// Feature 的默认值字段分别为普通类型和可空类型
+type Feature struct {
+	ID     int          `qsy:"primarykey;autoincrement"`
+	Active bool         `qsy:"default:true"`
+	Rank   *int         `qsy:"default:5"`
+	Seen   sql.NullTime `qsy:"default:CURRENT_TIMESTAMP"`
+}
+
+func TestInsertDefaults(t *testing.T) {
+	s := newTestSession(t, &Feature{})
+
+	// 可空字段未设置时由列默认值生效，零值的 bool 按给出的值写入
+	zero := 0
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	features := []Feature{{}, {Active: true, Rank: &zero, Seen: sql.NullTime{Time: seen, Valid: true}}}
+	if _, err := s.Insert(features); err != nil {
+		t.Fatal("插入记录失败:", err)
+	}
+	if features[0].ID == 0 || features[1].ID == 0 {
+		t.Fatalf("分组插入后 ID 未回填: %+v", features)
+	}
+	var unset, set Feature
+	if err := s.Get(&unset, features[0].ID); err != nil || unset.Active || unset.Rank == nil || *unset.Rank != 5 || !unset.Seen.Valid {
+		t.Fatalf("默认值错误: %+v, %v", unset, err)
+	}
+	if err := s.Get(&set, features[1].ID); err != nil || !set.Active || set.Rank == nil || *set.Rank != 0 || !set.Seen.Time.Equal(seen) {
+		t.Fatalf("给出的零值被默认值覆盖: %+v, %v", set, err)
+	}
+}
+
+// Keyword 的列名是 SQL 保留字
+type Keyword struct {
+	ID    int    `qsy:"primarykey;autoincrement"`
+	Order int    `qsy:"name:order;index"`
+	Group string `qsy:"name:group;uniqueIndex:idx_keyword_group_order,priority:1"`
+	Check string `qsy:"name:check;index:idx_keyword_group_order,priority:2"`
+}
+
+func TestCreateTableReservedWords(t *testing.T) {
+	s := newTestSession(t, &Keyword{})
+	if err := s.DropTable(); err != nil {
+		t.Fatal("删除表失败:", err)
+	}
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("保留字列名建表失败:", err)
+	}
+
+	keyword := &Keyword{Order: 1, Group: "g", Check: "c"}
+	if _, err := s.Insert(keyword); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if _, err := s.Update(map[string]interface{}{"Order": 2}, "ID = ?", keyword.ID); err != nil {
+		t.Fatal("更新失败:", err)
+	}
+	var keywords []Keyword
+	if err := s.Where(map[string]interface{}{"Group": "g"}).Find(&keywords); err != nil || len(keywords) != 1 || keywords[0].Order != 2 {
+		t.Fatalf("查询结果错误: %+v, %v", keywords, err)
+	}
+}
+
+// ReservedKey 的主键列名是 SQL 保留字
+type ReservedKey struct {
+	Order int `qsy:"name:order;primarykey;autoincrement"`
+	Name  string
+}
+
+func TestReservedPrimaryKeyOrder(t *testing.T) {
+	s := newTestSession(t, &ReservedKey{})
+	if _, err := s.Insert(&ReservedKey{Name: "a"}, &ReservedKey{Name: "b"}, &ReservedKey{Name: "c"}); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	// First/Last/FindInBatches 按主键排序时同样要给列名加引号
+	var first, last ReservedKey
+	if err := s.First(&first); err != nil || first.Name != "a" {
+		t.Fatalf("First 结果错误: %+v, %v", first, err)
+	}
+	if err := s.Last(&last); err != nil || last.Name != "c" {
+		t.Fatalf("Last 结果错误: %+v, %v", last, err)
+	}
+	var batch []ReservedKey
+	var names []string
+	err := s.FindInBatches(&batch, 2, func(_ *qsysession.Session, _ int) error {
+		for _, k := range batch {
+			names = append(names, k.Name)
+		}
+		return nil
+	})
+	if err != nil || len(names) != 3 {
+		t.Fatalf("FindInBatches 结果错误: %v, %v", names, err)
+	}
+}
@@ -0,0 +1,170 @@
+package qsysession
+
+import (
+	"fmt"
+	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
+	"reflect"
+)
+
+// snapshotKey identifies a loaded record by model, table and primary key;
+// the same record read from an archive table (see Table) is tracked apart
+type snapshotKey struct {
+	model reflect.Type
+	table string
+	pk    string
+}
+
+// recordKey returns the snapshot key of v, a struct of the session model,
+// in the table of the current operation; records without a primary key
+// value cannot be tracked
+func (s *Session) recordKey(v reflect.Value) (snapshotKey, bool) {
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) == 0 {
+		return snapshotKey{}, false
+	}
+	pks := make([]interface{}, len(primaries))
+	for i, field := range primaries {
+		value := s.fieldValue(v, field)
+		if value.IsZero() {
+			return snapshotKey{}, false
+		}
+		pks[i] = value.Interface()
+	}
+	return snapshotKey{model: v.Type(), table: s.tableName(), pk: fmt.Sprintf("%#v", pks)}, true
+}
+
+// takeSnapshot remembers the values of fields in v as loaded from the
+// database, replacing any earlier snapshot of the same record
+func (s *Session) takeSnapshot(v reflect.Value, fields []*qsyschema.Field) {
+	key, ok := s.recordKey(v)
+	if !ok {
+		return
+	}
+	snapshot := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		snapshot[field.Name] = copyValue(s.fieldValue(v, field))
+	}
+	if s.snapshots == nil {
+		s.snapshots = make(map[snapshotKey]map[string]interface{})
+	}
+	s.snapshots[key] = snapshot
+	s.forgetOnRollback(key)
+}
+
+// forgetOnRollback drops the snapshot of key if the current transaction
+// is rolled back: it may hold values that never reached the database, and
+// an untracked record is written in full by the next update
+func (s *Session) forgetOnRollback(key snapshotKey) {
+	s.onRollback(func() { delete(s.snapshots, key) })
+}
+
+// refreshSnapshot records the values written by an update of v's own row;
+// columns assigned an expression or a value other than v's, e.g. by Set,
+// are forgotten since the record does not hold what was written
+func (s *Session) refreshSnapshot(v reflect.Value, assignments qsyclause.Assignments) {
+	key, ok := s.recordKey(v)
+	if !ok || s.snapshots[key] == nil {
+		return
+	}
+	snapshot := s.snapshots[key]
+	s.forgetOnRollback(key)
+	for _, assignment := range assignments {
+		field := s.lookupField(assignment.Column)
+		if field == nil {
+			continue
+		}
+		value := s.fieldValue(v, field)
+		if _, isExpr := assignment.Value.(qsyclause.Expression); isExpr || !reflect.DeepEqual(assignment.Value, value.Interface()) {
+			delete(snapshot, field.Name)
+			continue
+		}
+		snapshot[field.Name] = copyValue(value)
+	}
+}
+
+// forgetSnapshot drops the snapshot of v, e.g. after its row was deleted
+func (s *Session) forgetSnapshot(v reflect.Value) {
+	if key, ok := s.recordKey(v); ok {
+		delete(s.snapshots, key)
+	}
+}
+
+// forgetSnapshots drops the snapshots of every record of the session model
+// in the table of the current operation, after a statement that changed rows the session cannot tell apart, such
+// as a condition update or delete; those records are written in full by
+// their next update
+func (s *Session) forgetSnapshots() {
+	model := reflect.Indirect(reflect.ValueOf(s.Schema.Model)).Type()
+	table := s.tableName()
+	for key := range s.snapshots {
+		if key.model == model && key.table == table {
+			delete(s.snapshots, key)
+		}
+	}
+}
+
+// changedFields returns the fields of v that differ from its snapshot;
+// fields missing from the snapshot count as changed. tracked is false
+// when v was not loaded by this session.
+func (s *Session) changedFields(v reflect.Value) (changed []*qsyschema.Field, tracked bool) {
+	key, ok := s.recordKey(v)
+	if !ok {
+		return nil, false
+	}
+	snapshot, ok := s.snapshots[key]
+	if !ok {
+		return nil, false
+	}
+	for _, field := range s.Schema.Fields {
+		old, ok := snapshot[field.Name]
+		if !ok || !reflect.DeepEqual(old, copyValue(s.fieldValue(v, field))) {
+			changed = append(changed, field)
+		}
+	}
+	return changed, true
+}
+
+// Changes returns the columns of record that differ from the values it
+// was loaded with by Find/First, as column -> [old, new]. It returns nil
+// for records this session has not loaded, and for those whose rows were
+// since deleted or written by a statement not scoped to them. Records
+// loaded from another table are looked up with Table.
+func (s *Session) Changes(record interface{}) map[string][2]interface{} {
+	defer s.resetStatement()
+	if s.Schema == nil {
+		return nil
+	}
+	v, err := s.modelValue(record)
+	if err != nil {
+		return nil
+	}
+	key, ok := s.recordKey(v)
+	if !ok || s.snapshots[key] == nil {
+		return nil
+	}
+	snapshot := s.snapshots[key]
+	changes := make(map[string][2]interface{})
+	for _, field := range s.Schema.Fields {
+		old, ok := snapshot[field.Name]
+		current := copyValue(s.fieldValue(v, field))
+		if ok && !reflect.DeepEqual(old, current) {
+			changes[field.DBName] = [2]interface{}{old, current}
+		}
+	}
+	return changes
+}
+
+// copyValue returns the value of v detached from the record, so later
+// changes through pointers or byte slices do not alter a snapshot
+func copyValue(v reflect.Value) interface{} {
+	switch {
+	case v.Kind() == reflect.Ptr && !v.IsNil():
+		elem := reflect.New(v.Type().Elem())
+		elem.Elem().Set(v.Elem())
+		return elem.Interface()
+	case v.Kind() == reflect.Slice && !v.IsNil():
+		return reflect.AppendSlice(reflect.MakeSlice(v.Type(), 0, v.Len()), v).Interface()
+	}
+	return v.Interface()
+}
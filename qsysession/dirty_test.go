@@ -0,0 +1,224 @@
+package qsysession_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"qsyorm/qsyclause"
+	"qsyorm/qsylog"
+	"qsyorm/qsysession"
+	"strings"
+	"testing"
+)
+
+func TestDirtyTracking(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	// 记录执行的 SQL，检查只写入了改动的列
+	var buf bytes.Buffer
+	s.Logger = qsylog.New(log.New(&buf, "", 0), qsylog.Config{Loglevel: qsylog.Info})
+
+	var user TestUser
+	if err := s.First(&user, "Name = ?", "张三"); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	if changes := s.Changes(&user); len(changes) != 0 {
+		t.Fatalf("未修改的记录不应有改动: %v", changes)
+	}
+	if s.Changes(&TestUser{ID: 99}) != nil {
+		t.Fatal("未加载的记录不应被跟踪")
+	}
+
+	// 没有改动时不执行语句
+	buf.Reset()
+	if affected, err := s.Save(&user); err != nil || affected != 0 || strings.Contains(buf.String(), "UPDATE") {
+		t.Fatalf("未改动的记录不应更新: %d, %v, %s", affected, err, buf.String())
+	}
+
+	user.Age = 26
+	changes := s.Changes(&user)
+	if len(changes) != 1 || changes["Age"] != [2]interface{}{25, 26} {
+		t.Fatalf("改动错误: %v", changes)
+	}
+
+	buf.Reset()
+	if affected, err := s.Save(&user); err != nil || affected != 1 {
+		t.Fatalf("保存失败: %d, %v", affected, err)
+	}
+	if sql := buf.String(); !strings.Contains(sql, `SET "Age" = ?`) || strings.Contains(sql, `"Name" =`) {
+		t.Fatalf("只应写入改动的列: %s", sql)
+	}
+	if changes := s.Changes(&user); len(changes) != 0 {
+		t.Fatalf("保存后快照未刷新: %v", changes)
+	}
+
+	var stored TestUser
+	if err := s.Get(&stored, user.ID); err != nil || stored.Age != 26 || stored.Name != "张三" {
+		t.Fatalf("保存结果错误: %+v, %v", stored, err)
+	}
+}
+
+func TestDirtyTrackingTables(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+	if err := s.Table("users_archive").CreateTable(); err != nil {
+		t.Fatal("创建归档表失败:", err)
+	}
+
+	// 从主表加载的记录保存到归档表时，归档表中没有快照，整行写入
+	var user TestUser
+	if err := s.First(&user, "Name = ?", "张三"); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	if affected, err := s.Table("users_archive").Save(&user); err != nil || affected != 1 {
+		t.Fatalf("保存到归档表失败: %d, %v", affected, err)
+	}
+	var archived TestUser
+	if err := s.Table("users_archive").First(&archived, "ID = ?", user.ID); err != nil || archived != user {
+		t.Fatalf("归档表记录错误: %+v, %v", archived, err)
+	}
+	if changes := s.Changes(&user); len(changes) != 0 {
+		t.Fatalf("主表快照不应受归档表写入影响: %v", changes)
+	}
+
+	// 从归档表加载的记录更新主表时，按主表的快照比较
+	archived.Age = 50
+	if _, err := s.Table("users_archive").Update(&archived); err != nil {
+		t.Fatal("更新归档表失败:", err)
+	}
+	if changes := s.Table("users_archive").Changes(&archived); len(changes) != 0 {
+		t.Fatalf("归档表快照未刷新: %v", changes)
+	}
+	if err := s.Table("users_archive").First(&archived, "ID = ?", user.ID); err != nil {
+		t.Fatal("查询归档表失败:", err)
+	}
+	if _, err := s.Update(&archived); err != nil {
+		t.Fatal("更新主表失败:", err)
+	}
+	var stored TestUser
+	if err := s.Get(&stored, user.ID); err != nil || stored.Age != 50 {
+		t.Fatalf("主表应写入归档记录的全部改动: %+v, %v", stored, err)
+	}
+}
+
+func TestDirtyTrackingRollback(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	var user TestUser
+	if err := s.First(&user, "Name = ?", "张三"); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+
+	// 回滚的事务中写入的值没有落库，快照被丢弃，之后的保存写入全部列
+	errAbort := errors.New("abort")
+	user.Age = 50
+	err := s.Transaction(func(tx *qsysession.Session) error {
+		if _, err := tx.Save(&user); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("期望事务返回 errAbort，实际 %v", err)
+	}
+	if s.Changes(&user) != nil {
+		t.Fatal("回滚后记录不应再被跟踪")
+	}
+	if n, err := s.Save(&user); err != nil || n != 1 {
+		t.Fatalf("回滚后保存失败: %d, %v", n, err)
+	}
+	var stored TestUser
+	if err := s.Get(&stored, user.ID); err != nil || stored.Age != 50 {
+		t.Fatalf("回滚后保存的改动丢失: %+v, %v", stored, err)
+	}
+
+	// 分批读取的记录不保存快照
+	var batch []TestUser
+	err = s.FindInBatches(&batch, 2, func(tx *qsysession.Session, _ int) error {
+		for i := range batch {
+			if batch[i].ID != user.ID && tx.Changes(&batch[i]) != nil {
+				return errors.New("batched record is tracked")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("分批读取错误:", err)
+	}
+}
+
+func TestDirtyTrackingWrites(t *testing.T) {
+	// 每种写入之后快照都要刷新或丢弃，否则把记录改回原值的更新会被跳过
+	tests := []struct {
+		name  string
+		write func(s *qsysession.Session, u *TestUser) error
+	}{
+		{"UpdateBatch", func(s *qsysession.Session, u *TestUser) error {
+			u.Age = 77
+			_, err := s.UpdateBatch([]*TestUser{u})
+			return err
+		}},
+		{"SelectWhere", func(s *qsysession.Session, u *TestUser) error {
+			u.Age = 66
+			_, err := s.Select("Age").Update(u, "ID = ?", u.ID)
+			return err
+		}},
+		{"SelectByKey", func(s *qsysession.Session, u *TestUser) error {
+			u.Age = 66
+			_, err := s.Select("Age").Update(u)
+			return err
+		}},
+		{"Map", func(s *qsysession.Session, u *TestUser) error {
+			_, err := s.Where("ID = ?", u.ID).Update(map[string]interface{}{"Age": 55})
+			return err
+		}},
+		{"Set", func(s *qsysession.Session, u *TestUser) error {
+			_, err := s.Set("Age", 55).Update(u)
+			return err
+		}},
+		{"Upsert", func(s *qsysession.Session, u *TestUser) error {
+			_, err := s.OnConflict(qsyclause.OnConflict{Columns: []string{"ID"}, DoUpdates: []string{"Age"}}).
+				Upsert(&TestUser{ID: u.ID, Name: u.Name, Age: 55})
+			return err
+		}},
+		{"Delete", func(s *qsysession.Session, u *TestUser) error {
+			if _, err := s.Delete(u); err != nil {
+				return err
+			}
+			_, err := s.Insert(&TestUser{ID: u.ID, Name: u.Name, Age: 1})
+			return err
+		}},
+		{"DeleteByID", func(s *qsysession.Session, u *TestUser) error {
+			if _, err := s.DeleteByID(u.ID); err != nil {
+				return err
+			}
+			_, err := s.Insert(&TestUser{ID: u.ID, Name: u.Name, Age: 1})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession(t, &TestUser{})
+			seedTestUsers(t, s)
+			var user TestUser
+			if err := s.First(&user, "Name = ?", "张三"); err != nil {
+				t.Fatal("查询失败:", err)
+			}
+			if err := tt.write(s, &user); err != nil {
+				t.Fatal("写入失败:", err)
+			}
+
+			user.Age = 25
+			if affected, err := s.Update(&user); err != nil || affected != 1 {
+				t.Fatalf("改回原值的更新被跳过: %d, %v", affected, err)
+			}
+			var stored TestUser
+			if err := s.Get(&stored, user.ID); err != nil || stored.Age != 25 {
+				t.Fatalf("更新结果错误: %+v, %v", stored, err)
+			}
+		})
+	}
+}
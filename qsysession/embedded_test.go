@@ -0,0 +1,70 @@
+package qsysession_test
+
+import (
+	"testing"
+	"time"
+)
+
+// Model 是各个模型共用的基础字段
+type Model struct {
+	ID        int `qsy:"primarykey;autoincrement"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Address struct {
+	City string
+	Zip  string
+}
+
+type Contact struct {
+	Note string
+}
+
+type Shop struct {
+	Model
+	*Contact
+	Name    string
+	Address Address `qsy:"embedded;embeddedPrefix:addr_"`
+}
+
+func TestEmbeddedFields(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := newTestSession(t, &Shop{}).WithClock(func() time.Time { return now })
+
+	var columns []string
+	if err := s.Raw("SELECT name FROM pragma_table_info('shop')").Scan(&columns); err != nil {
+		t.Fatal("读取表结构失败:", err)
+	}
+	if len(columns) != 7 || columns[0] != "ID" || columns[3] != "Note" || columns[5] != "addr_City" {
+		t.Fatalf("嵌入字段未展开: %v", columns)
+	}
+
+	shop := &Shop{Name: "书店", Address: Address{City: "杭州", Zip: "310000"}}
+	if _, err := s.Insert(shop); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if shop.ID != 1 || !shop.CreatedAt.Equal(now) {
+		t.Fatalf("嵌入字段未回填: %+v", shop.Model)
+	}
+
+	var found Shop
+	if err := s.First(&found, "addr_City = ?", "杭州"); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	if found.ID != 1 || found.Address.Zip != "310000" || found.Contact == nil || found.Note != "" {
+		t.Fatalf("嵌入字段读取错误: %+v", found)
+	}
+
+	found.Address.City = "上海"
+	found.Note = "总店"
+	if _, err := s.Save(&found); err != nil {
+		t.Fatal("保存失败:", err)
+	}
+	if changes := s.Changes(&found); len(changes) != 0 {
+		t.Fatalf("保存后仍有改动: %v", changes)
+	}
+	if n, err := s.Count(map[string]interface{}{"Address.City": "上海", "Note": "总店"}); err != nil || n != 1 {
+		t.Fatalf("按嵌套字段计数错误: %d, %v", n, err)
+	}
+}
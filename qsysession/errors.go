@@ -0,0 +1,16 @@
+package qsysession
+
+import "errors"
+
+var (
+	// ErrRecordNotFound is returned by First/Last/Take/Get when no row matches
+	ErrRecordNotFound = errors.New("record not found")
+
+	// ErrMissingWhereClause is returned by an Update, Delete or Restore
+	// without conditions, see Session.AllowGlobalUpdate
+	ErrMissingWhereClause = errors.New("missing where clause, use AllowGlobalUpdate to affect every row")
+
+	// ErrStaleObject is returned by Update/Save of a versioned record when
+	// the row was changed or deleted since the record was read
+	ErrStaleObject = errors.New("stale object")
+)
@@ -0,0 +1,74 @@
+package qsysession
+
+import (
+	"errors"
+	"fmt"
+	"qsyorm/qsyclause"
+	"reflect"
+)
+
+// First loads the first record ordered by primary key into dest
+func (s *Session) First(dest interface{}, conds ...interface{}) error {
+	return s.findOne(dest, true, false, conds)
+}
+
+// Last loads the last record ordered by primary key into dest
+func (s *Session) Last(dest interface{}, conds ...interface{}) error {
+	return s.findOne(dest, true, true, conds)
+}
+
+// Take loads one record into dest without any ordering
+func (s *Session) Take(dest interface{}, conds ...interface{}) error {
+	return s.findOne(dest, false, false, conds)
+}
+
+// Get loads the record whose primary key equals pk into dest.
+// Composite keys take one value per primary key field, in declaration order.
+func (s *Session) Get(dest interface{}, pk ...interface{}) error {
+	if s.Schema == nil {
+		s.resetStatement()
+		return errors.New("schema is nil")
+	}
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) == 0 || len(primaries) != len(pk) {
+		s.resetStatement()
+		return fmt.Errorf("model %s has %d primary key fields, got %d values", s.Schema.Name, len(primaries), len(pk))
+	}
+	for i, field := range primaries {
+		s.Where(qsyclause.Eq{Column: field.DBName, Value: pk[i]})
+	}
+	return s.findOne(dest, false, false, nil)
+}
+
+// findOne runs Find with LIMIT 1 and copies the single result into dest
+func (s *Session) findOne(dest interface{}, byPrimary, desc bool, conds []interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Struct {
+		s.resetStatement()
+		return errors.New("dest must be a pointer to struct")
+	}
+	if s.Schema == nil {
+		s.resetStatement()
+		return errors.New("schema is nil")
+	}
+
+	if byPrimary {
+		for _, field := range s.Schema.PrimaryFields() {
+			order := s.dialect.Quote(field.DBName)
+			if desc {
+				order += " DESC"
+			}
+			s.Order(order)
+		}
+	}
+
+	results := reflect.New(reflect.SliceOf(destValue.Elem().Type()))
+	if err := s.Limit(1).Find(results.Interface(), conds...); err != nil {
+		return err
+	}
+	if results.Elem().Len() == 0 {
+		return ErrRecordNotFound
+	}
+	destValue.Elem().Set(results.Elem().Index(0))
+	return nil
+}
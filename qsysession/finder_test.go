@@ -0,0 +1,50 @@
+package qsysession_test
+
+import (
+	"errors"
+	"qsyorm/qsysession"
+	"testing"
+)
+
+func TestFindOne(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	var user TestUser
+	if err := s.First(&user); err != nil || user.Name != "张三" {
+		t.Fatalf("First 结果错误: %v, %v", user, err)
+	}
+
+	user = TestUser{}
+	if err := s.Last(&user); err != nil || user.Name != "赵六" {
+		t.Fatalf("Last 结果错误: %v, %v", user, err)
+	}
+
+	user = TestUser{}
+	if err := s.Where("Age > ?", 30).First(&user); err != nil || user.Name != "王五" {
+		t.Fatalf("条件 First 结果错误: %v, %v", user, err)
+	}
+
+	user = TestUser{}
+	if err := s.Take(&user, "Name = ?", "李四"); err != nil || user.Age != 30 {
+		t.Fatalf("Take 结果错误: %v, %v", user, err)
+	}
+
+	user = TestUser{}
+	if err := s.Get(&user, 3); err != nil || user.Name != "王五" {
+		t.Fatalf("Get 结果错误: %v, %v", user, err)
+	}
+
+	if err := s.Get(&user, 100); !errors.Is(err, qsysession.ErrRecordNotFound) {
+		t.Fatalf("期望 ErrRecordNotFound，实际 %v", err)
+	}
+	if err := s.First(&user, "Age > ?", 100); !errors.Is(err, qsysession.ErrRecordNotFound) {
+		t.Fatalf("期望 ErrRecordNotFound，实际 %v", err)
+	}
+	if err := s.Get(&user, 1, 2); err == nil {
+		t.Fatal("期望主键数量不匹配报错")
+	}
+	if err := s.First(user); err == nil {
+		t.Fatal("期望非指针 dest 报错")
+	}
+}
@@ -116,6 +116,9 @@ func TestHook(t *testing.T) {
 		t.Fatal("插入记录失败:", err)
 	}
 	t.Logf("插入成功，ID: %d, CreatedAt: %s", id, user.CreatedAt)
+	if int64(user.ID) != id {
+		t.Fatalf("插入后未回填 ID，期望: %d, 实际: %d", id, user.ID)
+	}
 
 	// 让我们直接用SQL检查创建的记录
 	var initialUpdatedAt string
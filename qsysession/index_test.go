@@ -0,0 +1,60 @@
+package qsysession_test
+
+import (
+	"testing"
+)
+
+type Member struct {
+	ID        int    `qsy:"primarykey;autoincrement"`
+	Username  string `qsy:"index:idx_member_username_lower,unique,expression:lower(Username)"`
+	Age       int    `qsy:"index:idx_member_city_age,priority:2,sort:desc"`
+	City      string `qsy:"index:idx_member_city_age,priority:1"`
+	Email     string `qsy:"uniqueIndex:idx_member_email,where:DeletedAt IS NULL"`
+	Nickname  string `qsy:"index"`
+	DeletedAt *int64
+}
+
+func TestCreateTableIndexes(t *testing.T) {
+	s := newTestSession(t, &Member{})
+
+	rows, err := s.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = 'member' AND sql IS NOT NULL").QueryRows()
+	if err != nil {
+		t.Fatal("读取索引失败:", err)
+	}
+	defer rows.Close()
+	indexes := map[string]string{}
+	for rows.Next() {
+		var name, sql string
+		if err := rows.Scan(&name, &sql); err != nil {
+			t.Fatal("读取索引失败:", err)
+		}
+		indexes[name] = sql
+	}
+
+	want := map[string]string{
+		"idx_member_username_lower": `CREATE UNIQUE INDEX "idx_member_username_lower" ON "member"(lower(Username))`,
+		"idx_member_city_age":       `CREATE INDEX "idx_member_city_age" ON "member"("City", "Age" DESC)`,
+		"idx_member_email":          `CREATE UNIQUE INDEX "idx_member_email" ON "member"("Email") WHERE DeletedAt IS NULL`,
+		"idx_member_nickname":       `CREATE INDEX "idx_member_nickname" ON "member"("Nickname")`,
+	}
+	if len(indexes) != len(want) {
+		t.Fatalf("索引数量错误: %v", indexes)
+	}
+	for name, sql := range want {
+		if indexes[name] != sql {
+			t.Errorf("索引 %s 错误: %q，期望 %q", name, indexes[name], sql)
+		}
+	}
+
+	// 表达式唯一索引忽略大小写，部分唯一索引只约束未删除的记录
+	if _, err := s.Insert(&Member{Username: "Alice", Email: "a@example.com"}); err != nil {
+		t.Fatal("插入记录失败:", err)
+	}
+	if _, err := s.Insert(&Member{Username: "alice", Email: "b@example.com"}); err == nil {
+		t.Fatal("期望表达式唯一索引拒绝重复用户名")
+	}
+	deleted := int64(1)
+	if _, err := s.Insert(&Member{Username: "bob", Email: "a@example.com", DeletedAt: &deleted}); err != nil {
+		t.Fatal("部分唯一索引不应约束已删除的记录:", err)
+	}
+}
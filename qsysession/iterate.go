@@ -0,0 +1,73 @@
+package qsysession
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"reflect"
+)
+
+// Iterate streams the records of model T matching the chained state and
+// conds, one row at a time, e.g.
+//
+//	for u, err := range qsysession.Iterate[User](session.Where("Age > ?", 18)) { ... }
+//
+// The statement is built when Iterate is called and executed when the
+// sequence is ranged over; sql.Rows is closed when the loop ends, even early.
+// The session timeout does not apply, since it would cut the loop off
+// midway; bound it through the session context instead.
+func Iterate[T any](s *Session, conds ...interface{}) iter.Seq2[T, error] {
+	defer s.resetStatement()
+	var zero T
+
+	modelType := reflect.TypeOf((*T)(nil)).Elem()
+	if modelType.Kind() != reflect.Struct {
+		return errorSeq[T](errors.New("iterate model must be a struct"))
+	}
+	if s.Schema == nil || reflect.Indirect(reflect.ValueOf(s.Schema.Model)).Type() != modelType {
+		s.Schema = typedSchema[T](s)
+	}
+	if err := s.applyConds(conds); err != nil {
+		return errorSeq[T](err)
+	}
+	fields, sqlStr, sqlVars, err := s.buildSelect()
+	if err != nil {
+		return errorSeq[T](err)
+	}
+
+	return func(yield func(T, error) bool) {
+		rows, err := s.Raw(sqlStr, sqlVars...).queryContext(s.Context())
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var record T
+			if err := s.scanRow(rows, fields, reflect.ValueOf(&record).Elem()); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// Iterate streams the matching records, see qsysession.Iterate
+func (q *TypedQuery[T]) Iterate(ctx context.Context) iter.Seq2[T, error] {
+	return Iterate[T](q.prepare(ctx))
+}
+
+// errorSeq yields a single error
+func errorSeq[T any](err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		yield(zero, err)
+	}
+}
@@ -0,0 +1,115 @@
+package qsysession_test
+
+import (
+	"context"
+	"qsyorm/qsysession"
+	"testing"
+	"time"
+)
+
+// IterUser 统计 AfterQuery 调用次数
+type IterUser struct {
+	ID      int `qsy:"primarykey;autoincrement"`
+	Name    string
+	Age     int
+	Queried bool
+}
+
+func (u *IterUser) AfterQuery() error {
+	u.Queried = true
+	return nil
+}
+
+func TestIterate(t *testing.T) {
+	s := newTestSession(t, &IterUser{})
+	users := make([]IterUser, 10)
+	for i := range users {
+		users[i] = IterUser{Name: "user", Age: i}
+	}
+	if _, err := s.Insert(users); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	var ages []int
+	for u, err := range qsysession.Iterate[IterUser](s.Where("Age >= ?", 5).Order("Age")) {
+		if err != nil {
+			t.Fatal("迭代失败:", err)
+		}
+		if !u.Queried {
+			t.Fatal("AfterQuery 未被调用")
+		}
+		ages = append(ages, u.Age)
+	}
+	if len(ages) != 5 || ages[0] != 5 || ages[4] != 9 {
+		t.Fatalf("迭代结果错误: %v", ages)
+	}
+
+	// 提前结束循环时关闭 sql.Rows，连接可以继续使用
+	s.DB().SetMaxOpenConns(1)
+	n := 0
+	for _, err := range qsysession.Iterate[IterUser](s) {
+		if err != nil {
+			t.Fatal("迭代失败:", err)
+		}
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	if count, err := s.Count(); err != nil || count != 10 {
+		t.Fatalf("提前结束后计数错误: %d, %v", count, err)
+	}
+
+	n = 0
+	for u, err := range qsysession.Typed[IterUser](s).Where("Age < ?", 2).Iterate(context.Background()) {
+		if err != nil || u.Age >= 2 {
+			t.Fatalf("typed 迭代结果错误: %v, %v", u, err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("typed 迭代期望2条记录，实际%d条", n)
+	}
+
+	for _, err := range qsysession.Iterate[IterUser](s.Select("Missing")) {
+		if err == nil {
+			t.Fatal("期望未知列报错")
+		}
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	s := newTestSession(t, &IterUser{})
+	users := make([]IterUser, 6)
+	for i := range users {
+		users[i] = IterUser{Name: "user", Age: i}
+	}
+	if _, err := s.Insert(users); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	// 语句超时限制单条语句，不截断处理较慢的流式读取
+	s.WithTimeout(20 * time.Millisecond)
+	n := 0
+	for _, err := range qsysession.Iterate[IterUser](s) {
+		if err != nil {
+			t.Fatal("迭代被超时中断:", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		n++
+	}
+	if n != 6 {
+		t.Fatalf("期望迭代6条记录，实际%d条", n)
+	}
+
+	var batch []IterUser
+	batches := 0
+	err := s.FindInBatches(&batch, 2, func(tx *qsysession.Session, _ int) error {
+		time.Sleep(30 * time.Millisecond)
+		batches++
+		return nil
+	})
+	if err != nil || batches != 3 {
+		t.Fatalf("分批查询被超时中断: %d, %v", batches, err)
+	}
+}
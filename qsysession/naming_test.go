@@ -0,0 +1,129 @@
+package qsysession_test
+
+import (
+	"context"
+	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
+	"qsyorm/qsysession"
+	"testing"
+)
+
+// LegacyUser 映射到一张已有的 snake_case 表
+type LegacyUser struct {
+	ID        int `qsy:"primarykey;autoincrement"`
+	FullName  string
+	UserGroup string `qsy:"name:grp"`
+	ViewCount int
+}
+
+func TestNamingStrategy(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	if _, err := s.Raw("CREATE TABLE legacy_users (id INTEGER PRIMARY KEY AUTOINCREMENT, full_name TEXT, grp TEXT, view_count INTEGER NOT NULL DEFAULT 0)").Exec(); err != nil {
+		t.Fatal("建表失败:", err)
+	}
+	if _, err := s.Raw("INSERT INTO legacy_users (full_name, grp) VALUES ('Ann', 'admin')").Exec(); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	s.WithNamer(qsyschema.NamingStrategy{SnakeCase: true, PluralTables: true}).Model(&LegacyUser{})
+	var users []LegacyUser
+	if err := s.Find(&users, map[string]interface{}{"UserGroup": "admin"}); err != nil || len(users) != 1 || users[0].FullName != "Ann" {
+		t.Fatalf("按命名策略查询失败: %v, %v", users, err)
+	}
+
+	bob := &LegacyUser{FullName: "Bob", UserGroup: "dev"}
+	if _, err := s.Insert(bob); err != nil || bob.ID != 2 {
+		t.Fatalf("按命名策略插入失败: %+v, %v", bob, err)
+	}
+	if _, err := s.Where("id = ?", bob.ID).Update(map[string]interface{}{"grp": "ops"}); err != nil {
+		t.Fatal("按列名更新失败:", err)
+	}
+
+	// 表达式中的 Go 字段名同样映射为列名
+	if _, err := s.Set("ViewCount", qsyclause.Increment("ViewCount", 2)).Where("id = ?", bob.ID).Update(nil); err != nil {
+		t.Fatal("Set 表达式更新失败:", err)
+	}
+	if _, err := s.Where("id = ?", bob.ID).Update(map[string]interface{}{"ViewCount": qsyclause.Decrement("ViewCount", 1)}); err != nil {
+		t.Fatal("map 表达式更新失败:", err)
+	}
+	var viewed LegacyUser
+	if err := s.Get(&viewed, bob.ID); err != nil || viewed.ViewCount != 1 {
+		t.Fatalf("表达式更新结果错误: %+v, %v", viewed, err)
+	}
+
+	var groups []string
+	if err := s.Order("id").Pluck("UserGroup", &groups); err != nil || len(groups) != 2 || groups[1] != "ops" {
+		t.Fatalf("Pluck 结果错误: %v, %v", groups, err)
+	}
+}
+
+// Post 通过 TableName() 使用旧表名
+type Post struct {
+	ID    int `qsy:"primarykey;autoincrement"`
+	Title string
+}
+
+func (*Post) TableName() string {
+	return "blog_posts"
+}
+
+func TestTableOverride(t *testing.T) {
+	s := newTestSession(t, &Post{})
+	if err := s.Table("post_2025").CreateTable(); err != nil {
+		t.Fatal("创建归档表失败:", err)
+	}
+
+	if _, err := s.Insert(&Post{Title: "current"}); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if _, err := s.Table("post_2025").Insert(&Post{Title: "old"}, &Post{Title: "older"}); err != nil {
+		t.Fatal("插入归档表失败:", err)
+	}
+	// Save 插入新记录时同样使用覆盖的表
+	if _, err := s.Table("post_2025").Save(&Post{Title: "saved"}); err != nil {
+		t.Fatal("保存到归档表失败:", err)
+	}
+
+	if n, err := s.Count(); err != nil || n != 1 {
+		t.Fatalf("blog_posts 计数错误: %d, %v", n, err)
+	}
+	var archived []Post
+	if err := s.Table("post_2025").Order("ID").Find(&archived); err != nil || len(archived) != 3 || archived[2].Title != "saved" {
+		t.Fatalf("归档表查询错误: %v, %v", archived, err)
+	}
+	if _, err := s.Table("post_2025").Delete("Title = ?", "older"); err != nil {
+		t.Fatal("归档表删除失败:", err)
+	}
+	if n, _ := s.Table("post_2025").Count(); n != 2 {
+		t.Fatalf("归档表删除后计数错误: %d", n)
+	}
+
+	var count int
+	if err := s.Raw("SELECT COUNT(*) FROM blog_posts").QueryRow().Scan(&count); err != nil || count != 1 {
+		t.Fatalf("TableName() 未生效: %d, %v", count, err)
+	}
+}
+
+// mapNamer 含有 map 字段，不能作为 map 的键
+type mapNamer struct {
+	tables map[string]string
+}
+
+func (n mapNamer) TableName(name string) string {
+	return n.tables[name]
+}
+
+func (mapNamer) ColumnName(name string) string {
+	return name
+}
+
+func TestTypedUncomparableNamer(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	s.WithNamer(mapNamer{tables: map[string]string{"TestUser": "testuser"}})
+	users, err := qsysession.Typed[TestUser](s).Where("Age > ?", 30).Find(context.Background())
+	if err != nil || len(users) != 2 {
+		t.Fatalf("不可比较的命名策略查询失败: %v, %v", users, err)
+	}
+}
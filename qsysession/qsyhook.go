@@ -1,9 +1,12 @@
 package qsysession
 
 import (
+	"context"
 	"reflect"
 )
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 // Hook 定义了数据库操作前后的钩子接口
 type Hook interface {
 	// 查询相关钩子
@@ -23,7 +26,9 @@ type Hook interface {
 	AfterDelete() error
 }
 
-// 调用对象的 BeforeQuery 方法（如果实现了 Hook 接口）
+// 调用对象的钩子方法（如果存在）
+// 钩子既可以是 BeforeInsert() error，也可以是 BeforeInsert(ctx context.Context) error，
+// 后者会收到会话的 context
 func (s *Session) CallMethod(value interface{}, method string) error {
 	// 获取对象的反射值
 	fm := reflect.ValueOf(value).MethodByName(method)
@@ -31,8 +36,17 @@ func (s *Session) CallMethod(value interface{}, method string) error {
 		return nil
 	}
 
+	var args []reflect.Value
+	switch {
+	case fm.Type().NumIn() == 0:
+	case fm.Type().NumIn() == 1 && fm.Type().In(0) == contextType:
+		args = []reflect.Value{reflect.ValueOf(s.Context())}
+	default:
+		return nil
+	}
+
 	// 调用该方法
-	values := fm.Call([]reflect.Value{})
+	values := fm.Call(args)
 	if len(values) > 0 {
 		if err, ok := values[0].Interface().(error); ok && err != nil {
 			return err
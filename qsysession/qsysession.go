@@ -1,12 +1,14 @@
 package qsysession
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"qsyorm/qsydialect"
 	"qsyorm/qsylog"
 	"qsyorm/qsyschema"
 	"strings"
+	"time"
 )
 
 type Session struct {
@@ -18,6 +20,13 @@ type Session struct {
 	Logger      qsylog.Interface
 	dialect     qsydialect.Dialect
 	schemaCache map[string]*qsyschema.Schema
+	statement   statement
+	ctx         context.Context
+	timeout     time.Duration
+	nowFunc     func() time.Time
+	namer       qsyschema.Namer
+	snapshots   map[snapshotKey]map[string]interface{} // 已加载记录的原始值，见 Changes
+	rollbacks   []func()                               // 事务回滚时撤销的内存修改，见 onRollback
 }
 
 func NewSession(db *sql.DB, log qsylog.Interface, d qsydialect.Dialect) *Session {
@@ -33,6 +42,67 @@ func (s *Session) DB() *sql.DB {
 	return s.db
 }
 
+// WithContext sets the context passed to every statement, transaction,
+// hook and logger of the session
+func (s *Session) WithContext(ctx context.Context) *Session {
+	s.ctx = ctx
+	return s
+}
+
+// WithTimeout sets the default timeout applied to each statement; 0 disables it.
+// QueryRow, QueryRows and Iterate, whose rows the caller reads, are not bound by it.
+func (s *Session) WithTimeout(timeout time.Duration) *Session {
+	s.timeout = timeout
+	return s
+}
+
+// WithNamer sets the naming strategy for models parsed by the session;
+// nil keeps the default qsyschema.NamingStrategy
+func (s *Session) WithNamer(namer qsyschema.Namer) *Session {
+	s.namer = namer
+	return s
+}
+
+// WithClock sets the clock used for automatic timestamps and soft deletion;
+// nil restores time.Now
+func (s *Session) WithClock(now func() time.Time) *Session {
+	s.nowFunc = now
+	return s
+}
+
+// now returns the current time of the session clock
+func (s *Session) now() time.Time {
+	if s.nowFunc == nil {
+		return time.Now()
+	}
+	return s.nowFunc()
+}
+
+// Context returns the session context, context.Background() if none was set
+func (s *Session) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// statementContext derives the context of a single statement from the
+// session context, applying the default timeout if one is set
+func (s *Session) statementContext() (context.Context, context.CancelFunc) {
+	if s.timeout > 0 {
+		return context.WithTimeout(s.Context(), s.timeout)
+	}
+	return s.Context(), func() {}
+}
+
+// logger returns the session logger bound to the session context
+func (s *Session) logger() qsylog.Interface {
+	if l, ok := s.Logger.(qsylog.ContextInterface); ok && s.ctx != nil {
+		return l.WithContext(s.ctx)
+	}
+	return s.Logger
+}
+
 func (s *Session) Raw(sql string, value ...interface{}) *Session {
 	s.sql.WriteString(sql)
 	s.sql.WriteString(" ")
@@ -42,40 +112,59 @@ func (s *Session) Raw(sql string, value ...interface{}) *Session {
 
 func (s *Session) Exec() (result sql.Result, err error) {
 	defer s.Clear()
+	ctx, cancel := s.statementContext()
+	defer cancel()
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
-	s.Logger.Info(s.sql.String(), s.sqlvars...)
+	s.logger().Info(s.sql.String(), s.sqlvars...)
 	if s.tx != nil {
-		result, err = s.tx.Exec(s.sql.String(), s.sqlvars...)
+		result, err = s.tx.ExecContext(ctx, s.sql.String(), s.sqlvars...)
 	} else {
-		result, err = s.DB().Exec(s.sql.String(), s.sqlvars...)
+		result, err = s.DB().ExecContext(ctx, s.sql.String(), s.sqlvars...)
 	}
 	if err != nil {
-		s.Logger.Error(err.Error())
+		s.logger().Error(err.Error())
 	}
 	return
 }
 
+// QueryRow and QueryRows hand the result back to the caller, so there is
+// no point at which a statement timeout could be released; like Iterate,
+// they run under the session context without the default timeout, which
+// callers can bound through WithContext. Find, Count, Scan and Pluck read
+// their rows before returning and do apply the timeout.
+
 func (s *Session) QueryRow() *sql.Row {
+	return s.queryRowContext(s.Context())
+}
+
+func (s *Session) QueryRows() (*sql.Rows, error) {
+	return s.queryContext(s.Context())
+}
+
+// queryRowContext runs the collected SQL as a single-row query under ctx
+func (s *Session) queryRowContext(ctx context.Context) *sql.Row {
 	defer s.Clear()
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
-	s.Logger.Info(s.sql.String(), s.sqlvars...)
+	s.logger().Info(s.sql.String(), s.sqlvars...)
 	if s.tx != nil {
-		return s.tx.QueryRow(s.sql.String(), s.sqlvars...)
+		return s.tx.QueryRowContext(ctx, s.sql.String(), s.sqlvars...)
 	}
-	return s.DB().QueryRow(s.sql.String(), s.sqlvars...)
+	return s.DB().QueryRowContext(ctx, s.sql.String(), s.sqlvars...)
 }
 
-func (s *Session) QueryRows() (rows *sql.Rows, err error) {
+// queryContext runs the collected SQL under ctx; the rows stay readable
+// until ctx is done
+func (s *Session) queryContext(ctx context.Context) (rows *sql.Rows, err error) {
 	defer s.Clear()
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
-	s.Logger.Info(s.sql.String(), s.sqlvars...)
+	s.logger().Info(s.sql.String(), s.sqlvars...)
 	if s.tx != nil {
-		rows, err = s.tx.Query(s.sql.String(), s.sqlvars...)
+		rows, err = s.tx.QueryContext(ctx, s.sql.String(), s.sqlvars...)
 	} else {
-		rows, err = s.DB().Query(s.sql.String(), s.sqlvars...)
+		rows, err = s.DB().QueryContext(ctx, s.sql.String(), s.sqlvars...)
 	}
 	if err != nil {
-		s.Logger.Error(err.Error())
+		s.logger().Error(err.Error())
 	}
 	return
 }
@@ -0,0 +1,77 @@
+package qsysession_test
+
+import (
+	"context"
+	"errors"
+	"qsyorm/qsylog"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+// ContextUser 的钩子接收会话的 context
+type ContextUser struct {
+	ID   int `qsy:"primarykey;autoincrement"`
+	Name string
+	seen interface{}
+}
+
+func (u *ContextUser) BeforeInsert(ctx context.Context) error {
+	u.seen = ctx.Value(ctxKey{})
+	return nil
+}
+
+// contextLogger 记录收到的 context
+type contextLogger struct {
+	qsylog.Interface
+	ctxs *[]context.Context
+}
+
+func (l contextLogger) WithContext(ctx context.Context) qsylog.Interface {
+	*l.ctxs = append(*l.ctxs, ctx)
+	return l
+}
+
+func TestSessionContext(t *testing.T) {
+	s := newTestSession(t, &ContextUser{})
+	var ctxs []context.Context
+	s.Logger = contextLogger{Interface: qsylog.Discard, ctxs: &ctxs}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request-1")
+	user := &ContextUser{Name: "张三"}
+	if _, err := s.WithContext(ctx).Insert(user); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if user.seen != "request-1" {
+		t.Fatalf("钩子未收到会话 context: %v", user.seen)
+	}
+	if len(ctxs) == 0 || ctxs[0].Value(ctxKey{}) != "request-1" {
+		t.Fatal("日志未收到会话 context")
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	var users []ContextUser
+	if err := s.WithContext(cancelled).Find(&users); !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望 context.Canceled，实际 %v", err)
+	}
+	if err := s.Begin(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望事务开始时返回 context.Canceled，实际 %v", err)
+	}
+
+	s.WithContext(context.Background()).WithTimeout(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, err := s.Raw("SELECT 1").Exec(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望 context.DeadlineExceeded，实际 %v", err)
+	}
+	// 结果交给调用方读取的查询不受语句超时限制
+	var one int
+	if err := s.Raw("SELECT 1").QueryRow().Scan(&one); err != nil || one != 1 {
+		t.Fatalf("QueryRow 不应受超时限制: %d, %v", one, err)
+	}
+
+	if err := s.WithTimeout(time.Second).Find(&users); err != nil || len(users) != 1 {
+		t.Fatalf("超时内查询失败: %v, %v", users, err)
+	}
+}
@@ -1,133 +1,293 @@
 package qsysession
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
 	"reflect"
+	"sort"
 	"strings"
 )
 
-// Insert adds a new record to the database
+// maxInsertVars keeps a batch insert under SQLite's default host-parameter
+// limit (SQLITE_MAX_VARIABLE_NUMBER, 999 before 3.32)
+const maxInsertVars = 999
+
+// Insert adds new records to the database. values may be struct pointers
+// or slices of structs; several records are written with multi-row
+// VALUES statements, chunked to stay under the bind-var limit, inside
+// one transaction. Generated auto-increment ids are written back into the
+// records before AfterInsert runs; records that already carry a non-zero
+// auto-increment key are inserted with that key.
+// Fields with a default tag are left out when unset, so the column default
+// applies: only nullable fields (pointers and sql.Null* types) can tell
+// unset from zero, and the record keeps its nil or invalid value. Zero
+// values of other types such as false, 0 and "" are written as given. It
+// returns the id of the last inserted row.
 func (s *Session) Insert(values ...interface{}) (int64, error) {
+	id, _, err := s.insertRecords(values)
+	return id, err
+}
+
+// nullable reports whether a field of type t can tell an unset value from
+// its zero value: a pointer, or a sql.Null* struct whose Valid flag is false
+// while unset. false, 0 and "" are values a caller may mean to write.
+func nullable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		return true
+	}
+	if t.Kind() != reflect.Struct || !reflect.PtrTo(t).Implements(scannerType) {
+		return false
+	}
+	valid, ok := t.FieldByName("Valid")
+	return ok && valid.Type.Kind() == reflect.Bool
+}
+
+// insertGroup holds the records written with the same columns
+type insertGroup struct {
+	names  []string
+	values []interface{}
+	rows   []interface{}
+}
+
+// insertRecords implements Insert and Upsert, returning the last inserted id
+// and the number of affected rows
+func (s *Session) insertRecords(values []interface{}) (id int64, affected int64, err error) {
+	defer s.resetStatement()
+	values = flattenValues(values)
 	if len(values) == 0 || s.Schema == nil {
-		return 0, errors.New("no values or schema provided")
+		return 0, 0, errors.New("no values or schema provided")
 	}
 
 	// 调用 BeforeInsert 钩子
 	for _, value := range values {
 		if err := s.CallBeforeInsert(value); err != nil {
-			return 0, err
+			return 0, 0, err
 		}
 	}
 
-	table := s.Schema.GetTableName()
-	fields := make([]string, 0)
-	vars := make([]interface{}, 0)
+	// 在钩子执行之后再读取字段值，这样钩子中的修改会被包含
+	reflectValues := make([]reflect.Value, len(values))
+	for i, value := range values {
+		if reflectValues[i], err = s.modelValue(value); err != nil {
+			return 0, 0, err
+		}
+	}
 
-	// 调试输出: 打印Schema信息
-	s.Logger.Info("Insert into table '%s', Schema has %d fields", table, len(s.Schema.Fields))
-	s.Logger.Info("DbFieldToGo mapping contents:")
-	for dbField, goField := range s.Schema.DbFieldToGo {
-		s.Logger.Info("  DB field '%s' -> Go field '%s'", dbField, goField)
+	auto := s.Schema.AutoIncrementField()
+	if len(s.Schema.Fields) == 0 || len(s.Schema.Fields) == 1 && auto != nil {
+		return 0, 0, fmt.Errorf("model %s has no insertable fields", s.Schema.Name)
 	}
 
-	// Get field names and values from the provided struct
-	for _, value := range values {
-		reflectValue := reflect.Indirect(reflect.ValueOf(value))
-		if reflectValue.Kind() != reflect.Struct {
-			return 0, errors.New("value must be a struct")
+	// 零值的 CreatedAt/UpdatedAt 填入当前时间，并写回记录；
+	// 零值的自增主键和未设置的可空默认值字段不写入，由数据库生成，按省略的列把记录分组
+	now := s.now()
+	var groups []*insertGroup
+	groupOf := make(map[string]*insertGroup)
+	for i, reflectValue := range reflectValues {
+		var names []string
+		row := make([]interface{}, 0, len(s.Schema.Fields))
+		for _, field := range s.Schema.Fields {
+			value := s.fieldValue(reflectValue, field)
+			switch {
+			case field == auto && value.IsZero():
+				continue
+			case field.IsVersion:
+				row = append(row, initVersion(value))
+			case field.HasDefault && nullable(field.GoType) && value.IsZero() &&
+				field.AutoCreateTime == qsyschema.AutoTimeNone && field.AutoUpdateTime == qsyschema.AutoTimeNone:
+				continue
+			default:
+				row = append(row, s.insertTimestamp(value, field, now))
+			}
+			names = append(names, field.DBName)
 		}
 
-		// 调试输出: 打印结构体的所有字段和值
-		s.Logger.Info("Struct type: %s", reflectValue.Type().Name())
-		for i := 0; i < reflectValue.NumField(); i++ {
-			field := reflectValue.Type().Field(i)
-			s.Logger.Info("  Go field: %s, Value: %v", field.Name, reflectValue.Field(i).Interface())
+		key := strings.Join(names, ",")
+		group := groupOf[key]
+		if group == nil {
+			group = &insertGroup{names: names}
+			groupOf[key] = group
+			groups = append(groups, group)
 		}
+		group.values = append(group.values, values[i])
+		group.rows = append(group.rows, row)
+	}
 
-		for i, field := range s.Schema.Fields {
-			// 跳过自增主键字段，让数据库自动处理
-			if field.IsAutoIncrement && field.IsPrimaryKey {
-				s.Logger.Info("  Skipping autoincrement primary key field: %s", field.Name)
-				continue
-			}
-			fields = append(fields, field.Name)
-
-			// 使用DbFieldToGo映射找到对应的Go结构体字段名
-			goFieldName, ok := s.Schema.DbFieldToGo[field.Name]
-			if !ok {
-				// 如果映射中没有，尝试直接使用字段名
-				goFieldName = field.Name
-				s.Logger.Info("  [WARNING] No mapping found for DB field '%s', using as-is", field.Name)
+	onConflict := s.statement.onConflict
+	insert := func(s *Session) error {
+		// 冲突时更新了哪些行无法得知，丢弃该模型的快照
+		if onConflict != nil {
+			s.forgetSnapshots()
+		}
+		for _, group := range groups {
+			// 给出主键的记录不需要回填
+			backfill := onConflict == nil && auto != nil && !containsString(group.names, auto.DBName)
+			lastID, n, err := s.insertGroup(group, onConflict, backfill)
+			if err != nil {
+				return err
 			}
+			id = lastID
+			affected += n
+		}
 
-			// 调试输出: 打印字段映射和反射结果
-			s.Logger.Info("  Processing field %d: DB field '%s' -> Go field '%s'", i, field.Name, goFieldName)
-			fieldValue := reflectValue.FieldByName(goFieldName)
-			if !fieldValue.IsValid() {
-				s.Logger.Error("  [ERROR] Cannot find Go field '%s' in struct %s", goFieldName, reflectValue.Type().Name())
-				// 尝试不区分大小写查找
-				for j := 0; j < reflectValue.NumField(); j++ {
-					structField := reflectValue.Type().Field(j)
-					if strings.EqualFold(structField.Name, goFieldName) {
-						s.Logger.Info("  [RECOVERY] Found case-insensitive match: '%s'", structField.Name)
-						fieldValue = reflectValue.Field(j)
-						break
-					}
-				}
-				if !fieldValue.IsValid() {
-					return 0, fmt.Errorf("cannot find field '%s' in struct %s", goFieldName, reflectValue.Type().Name())
-				}
+		// 调用 AfterInsert 钩子
+		for _, value := range values {
+			if err := s.CallAfterInsert(value); err != nil {
+				return err
 			}
-
-			vars = append(vars, fieldValue.Interface())
-			s.Logger.Info("  Added field value: %v", fieldValue.Interface())
 		}
+		return nil
 	}
 
-	// Build the SQL statement
-	builder := qsyclause.New()
+	// 多条记录放在同一个事务中，已经处于事务中时直接复用
+	if len(values) > 1 && s.tx == nil {
+		err = s.Transaction(insert)
+	} else {
+		err = insert(s)
+	}
+	return id, affected, err
+}
 
-	// Use the BuildInsert helper to create the INSERT clause
-	sql, _ := qsyclause.BuildInsert(table, fields)
-	builder.Set(qsyclause.INSERT, sql)
+// insertGroup writes the records of group with multi-row INSERT statements
+// chunked under the bind-var limit, writing generated ids back when backfill
+// is set; it returns the last inserted id and the affected rows
+func (s *Session) insertGroup(group *insertGroup, onConflict *qsyclause.OnConflict, backfill bool) (id int64, affected int64, err error) {
+	chunkSize := 1
+	if len(group.names) > 0 && maxInsertVars/len(group.names) > 0 {
+		chunkSize = maxInsertVars / len(group.names)
+	}
+	for start := 0; start < len(group.rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(group.rows) {
+			end = len(group.rows)
+		}
 
-	// Combine all clauses
-	sqlStr, sqlVars := builder.Build(qsyclause.INSERT)
+		builder := s.newBuilder()
+		if len(group.names) == 0 {
+			// 所有列都使用默认值
+			builder.Set(qsyclause.INSERT, fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", s.quotedTable()))
+		} else {
+			insertSql, _ := qsyclause.BuildInsertInto(s.quotedTable(), s.quoteNames(group.names))
+			builder.Set(qsyclause.INSERT, insertSql)
+			valuesSql, valuesVars := qsyclause.BuildValues(group.rows[start:end]...)
+			setClause(builder, qsyclause.VALUES, valuesSql, valuesVars)
+		}
+		if onConflict != nil {
+			builder.Set(qsyclause.ONCONFLICT, *onConflict)
+		}
 
-	// 调试输出: 打印最终SQL和参数
-	s.Logger.Info("Final SQL: %s", sqlStr)
-	s.Logger.Info("Final SQL vars: %v, fields values: %v", sqlVars, vars)
+		sqlStr, sqlVars := builder.Build(qsyclause.INSERT, qsyclause.VALUES, qsyclause.ONCONFLICT)
+		result, err := s.Raw(sqlStr, sqlVars...).Exec()
+		if err != nil {
+			s.Logger.Error("Insert execution failed: %v", err)
+			return 0, 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, err
+		}
+		affected += n
+		if id, err = result.LastInsertId(); err != nil {
+			return 0, 0, err
+		}
+		// 冲突的行不会分配新的 ID，upsert 时无法可靠回填
+		if backfill {
+			s.backfillIDs(group.values[start:end], id)
+		}
+	}
+	return id, affected, nil
+}
 
-	// Add the query to the session and execute it
-	s.Raw(sqlStr, append(sqlVars, vars...)...)
-	result, err := s.Exec()
-	if err != nil {
-		s.Logger.Error("Insert execution failed: %v", err)
-		return 0, err
+// backfillIDs writes the generated ids into the auto-increment primary key
+// of the records of one INSERT statement. SQLite hands out consecutive ids
+// within a statement, so the first record got lastID-len(values)+1.
+// If the transaction is rolled back the ids are reset to zero, so that
+// retrying the insert assigns them again.
+func (s *Session) backfillIDs(values []interface{}, lastID int64) {
+	field := s.Schema.AutoIncrementField()
+	if field == nil {
+		return
+	}
+	firstID := lastID - int64(len(values)) + 1
+	var filled []reflect.Value
+	for i, value := range values {
+		reflectValue := reflect.ValueOf(value)
+		if reflectValue.Kind() != reflect.Ptr {
+			continue
+		}
+		fieldValue := s.fieldValue(reflectValue.Elem(), field)
+		if !fieldValue.CanSet() || !fieldValue.IsZero() {
+			continue
+		}
+		setInteger(fieldValue, firstID+int64(i))
+		filled = append(filled, fieldValue)
+	}
+	if len(filled) > 0 {
+		s.onRollback(func() {
+			for _, v := range filled {
+				v.Set(reflect.Zero(v.Type()))
+			}
+		})
 	}
+}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
+// setInteger stores n into an integer field of any width or signedness
+func setInteger(v reflect.Value, n int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(uint64(n))
 	}
+}
 
-	// 调用 AfterInsert 钩子
+// flattenValues expands slice arguments so that every element is a single
+// record, addressed through a pointer when possible so hooks can modify it
+func flattenValues(values []interface{}) []interface{} {
+	flat := make([]interface{}, 0, len(values))
 	for _, value := range values {
-		if err := s.CallAfterInsert(value); err != nil {
-			return id, err
+		rv := reflect.Indirect(reflect.ValueOf(value))
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			flat = append(flat, value)
+			continue
+		}
+		for i := 0; i < rv.Len(); i++ {
+			elem := rv.Index(i)
+			if elem.Kind() != reflect.Ptr && elem.CanAddr() {
+				elem = elem.Addr()
+			}
+			flat = append(flat, elem.Interface())
 		}
 	}
+	return flat
+}
 
-	return id, nil
+// modelValue dereferences value and checks that it matches the session model
+func (s *Session) modelValue(value interface{}) (reflect.Value, error) {
+	reflectValue := reflect.Indirect(reflect.ValueOf(value))
+	if reflectValue.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("value must be a struct")
+	}
+	if reflectValue.Type() != reflect.Indirect(reflect.ValueOf(s.Schema.Model)).Type() {
+		return reflect.Value{}, fmt.Errorf("value of type %s does not match model %s", reflectValue.Type(), s.Schema.Name)
+	}
+	return reflectValue, nil
 }
 
 // Find retrieves records from the database
-func (s *Session) Find(dest interface{}, where string, vars ...interface{}) error {
+// conds are optional inline conditions, e.g. Find(&users, "Age > ?", 18),
+// combined with any conditions chained through Where/Or/Not
+func (s *Session) Find(dest interface{}, conds ...interface{}) error {
+	defer s.resetStatement()
 	if s.Schema == nil {
 		return errors.New("schema is nil")
 	}
+	if err := s.applyConds(conds); err != nil {
+		return err
+	}
 
 	// Ensure dest is a pointer to slice
 	destValue := reflect.ValueOf(dest)
@@ -135,298 +295,677 @@ func (s *Session) Find(dest interface{}, where string, vars ...interface{}) erro
 		return errors.New("dest must be a pointer to slice")
 	}
 
-	// Get field names from schema
-	fields := make([]string, 0, len(s.Schema.Fields))
-	for _, field := range s.Schema.Fields {
-		fields = append(fields, field.Name)
+	fields, sqlStr, sqlVars, err := s.buildSelect()
+	if err != nil {
+		return err
 	}
 
-	// Build the SQL statement
-	builder := qsyclause.New()
-
-	// Build the SELECT statement
-	selectSql, _ := qsyclause.BuildSelect(s.Schema.GetTableName(), fields, "")
-	builder.Set(qsyclause.SELECT, selectSql)
-
-	// Add WHERE clause if provided
-	if where != "" {
-		whereSql, whereVars := qsyclause.BuildWhere(where, vars...)
-		builder.Set(qsyclause.WHERE, whereSql)
+	// Execute the query
+	ctx, cancel := s.statementContext()
+	defer cancel()
+	rows, err := s.Raw(sqlStr, sqlVars...).queryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-		// Now we need to add the WHERE variables by rebuilding
-		sqlStr, sqlVars := builder.Build(qsyclause.SELECT, qsyclause.WHERE)
+	// Element type of the slice
+	elemType := destValue.Elem().Type().Elem()
 
-		// Execute the query with the additional where vars
-		s.Raw(sqlStr, append(sqlVars, whereVars...)...)
-		rows, err := s.QueryRows()
-		if err != nil {
+	// Scan results into the destination slice
+	for rows.Next() {
+		// Create a new element of the slice type
+		newElem := reflect.New(elemType).Elem()
+		if err := s.scanRow(rows, fields, newElem); err != nil {
 			return err
 		}
-		defer rows.Close()
+		if !s.statement.untracked {
+			s.takeSnapshot(newElem, fields)
+		}
 
-		// Element type of the slice
-		elemType := destValue.Elem().Type().Elem()
+		// Append the new element to the result slice
+		destValue.Elem().Set(reflect.Append(destValue.Elem(), newElem))
+	}
 
-		// Scan results into the destination slice
-		for rows.Next() {
-			// Create a new element of the slice type
-			newElem := reflect.New(elemType).Elem()
+	return rows.Err()
+}
 
-			// Create a slice to hold the field addresses for scanning
-			values := make([]interface{}, len(fields))
-			for i, field := range s.Schema.Fields {
-				// 使用DbFieldToGo映射找到对应的Go结构体字段名
-				goFieldName, ok := s.Schema.DbFieldToGo[field.Name]
-				if !ok {
-					// 如果映射中没有，尝试直接使用字段名
-					goFieldName = field.Name
-				}
+// buildSelect renders the SELECT statement for the chained state and
+// returns the schema fields in the order of the selected columns
+func (s *Session) buildSelect() ([]*qsyschema.Field, string, []interface{}, error) {
+	// Get field names from schema, restricted by Select if given
+	fields, err := s.selectFields()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.DBName)
+	}
 
-				fieldValue := newElem.FieldByName(goFieldName)
-				values[i] = fieldValue.Addr().Interface()
-			}
+	// Build the SQL statement
+	builder := s.newBuilder()
+	selectSql, _ := qsyclause.BuildSelect(s.quotedTable(), s.quoteNames(names), "")
+	builder.Set(qsyclause.SELECT, selectSql)
+	s.buildWhere(builder)
+	s.buildPagination(builder)
+	sqlStr, sqlVars := builder.Build(qsyclause.SELECT, qsyclause.WHERE, qsyclause.ORDERBY, qsyclause.LIMIT, qsyclause.OFFSET)
+	return fields, sqlStr, sqlVars, nil
+}
 
-			// Scan the row into the values
-			if err := rows.Scan(values...); err != nil {
-				return err
-			}
+// scanRow scans the current row into elem, an addressable struct value,
+// and runs its AfterQuery hook
+func (s *Session) scanRow(rows *sql.Rows, fields []*qsyschema.Field, elem reflect.Value) error {
+	// Create a slice to hold the field addresses for scanning
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		values[i] = s.fieldValue(elem, field).Addr().Interface()
+	}
 
-			// 对每个记录调用 AfterQuery 钩子
-			newObj := newElem.Addr().Interface()
-			if err := s.CallAfterQuery(newObj); err != nil {
-				return err
-			}
+	// Scan the row into the values
+	if err := rows.Scan(values...); err != nil {
+		return err
+	}
 
-			// Append the new element to the result slice
-			destValue.Elem().Set(reflect.Append(destValue.Elem(), newElem))
-		}
+	// 对每个记录调用 AfterQuery 钩子
+	return s.CallAfterQuery(elem.Addr().Interface())
+}
 
-		return rows.Err()
-	} else {
-		// No WHERE clause, just execute the SELECT
-		sqlStr, sqlVars := builder.Build(qsyclause.SELECT)
+// Update modifies existing records in the database.
+// value is either a struct, whose columns are all written unless narrowed
+// with Select/Omit, or a map[string]interface{} keyed by column or Go field
+// name, which writes only the given columns (zero values included).
+// Map values and Set may be qsyclause expressions such as
+// qsyclause.Increment("Views", 1), rendered inline in the SET clause.
+// UpdatedAt columns are set to the session clock unless assigned explicitly
+// or omitted; Select does not leave them out.
+// A struct with a version field only updates its own row, by primary key,
+// while it is still at its version, increments it and writes it back;
+// otherwise ErrStaleObject is returned.
+// A struct passed without conditions updates its own row by primary key,
+// as Save does. Any other update without conditions is refused with
+// ErrMissingWhereClause unless AllowGlobalUpdate is chained.
+// A struct loaded by this session's Find/First only writes the columns that
+// changed since, and nothing at all when none did (see Changes). Updates
+// that are not scoped to the struct's own row drop the snapshots of the
+// model, so its loaded records are written in full by their next update.
+// value may be nil when the columns come from Set alone.
+// For map and Set-only updates the hooks run on a new instance of the model.
+func (s *Session) Update(value interface{}, conds ...interface{}) (int64, error) {
+	affected, _, err := s.update(value, conds)
+	return affected, err
+}
 
-		// Execute the query
-		s.Raw(sqlStr, sqlVars...)
-		rows, err := s.QueryRows()
-		if err != nil {
-			return err
+// update implements Update; skipped reports that a tracked record had no
+// changes, so no statement was run
+func (s *Session) update(value interface{}, conds []interface{}) (affected int64, skipped bool, err error) {
+	defer s.resetStatement()
+	if s.Schema == nil {
+		return 0, false, errors.New("schema is nil")
+	}
+	if err := s.applyConds(conds); err != nil {
+		return 0, false, err
+	}
+
+	values, isMap := value.(map[string]interface{})
+	hookTarget := value
+	if isMap || value == nil {
+		hookTarget = s.hookModel()
+	}
+	var record reflect.Value
+	if !isMap && value != nil {
+		if record, err = s.modelValue(value); err != nil {
+			return 0, false, err
+		}
+	}
+
+	// 没有条件的结构体只更新自己那一行，与 Save 一样按主键定位；
+	// 其它没有条件的更新会修改所有行，需要 AllowGlobalUpdate
+	byPrimaryKey := false
+	if len(s.statement.where) == 0 {
+		var pks []qsyclause.Expression
+		if record.IsValid() {
+			pks, byPrimaryKey = s.primaryKeyExprs(record)
 		}
-		defer rows.Close()
+		switch {
+		case byPrimaryKey:
+			s.statement.where = pks
+		case !s.statement.allowGlobal:
+			return 0, false, ErrMissingWhereClause
+		}
+	}
 
-		// Element type of the slice
-		elemType := destValue.Elem().Type().Elem()
+	// 调用 BeforeUpdate 钩子
+	if err := s.CallBeforeUpdate(hookTarget); err != nil {
+		return 0, false, err
+	}
 
-		// Scan results into the destination slice
-		for rows.Next() {
-			// Create a new element of the slice type
-			newElem := reflect.New(elemType).Elem()
+	// 获取字段名和值 - 在调用钩子后获取，这样钩子中的修改会被包含
+	var assignments qsyclause.Assignments
+	switch {
+	case isMap:
+		assignments, err = s.mapAssignments(values)
+	case value != nil:
+		assignments, err = s.structAssignments(value)
+	}
+	if err != nil {
+		return 0, false, err
+	}
 
-			// Create a slice to hold the field addresses for scanning
-			values := make([]interface{}, len(fields))
-			for i, field := range s.Schema.Fields {
-				// 使用DbFieldToGo映射找到对应的Go结构体字段名
-				goFieldName, ok := s.Schema.DbFieldToGo[field.Name]
-				if !ok {
-					// 如果映射中没有，尝试直接使用字段名
-					goFieldName = field.Name
+	// 由本会话加载的记录只写入改动过的列，没有改动时不执行语句
+	if record.IsValid() && len(s.statement.selects) == 0 {
+		if changed, tracked := s.changedFields(record); tracked {
+			kept := assignments[:0]
+			for _, assignment := range assignments {
+				if field := s.lookupField(assignment.Column); containsField(changed, field) {
+					kept = append(kept, assignment)
 				}
-
-				fieldValue := newElem.FieldByName(goFieldName)
-				values[i] = fieldValue.Addr().Interface()
 			}
-
-			// Scan the row into the values
-			if err := rows.Scan(values...); err != nil {
-				return err
+			assignments = kept
+			if len(assignments) == 0 && len(s.statement.sets) == 0 {
+				return 0, true, nil
 			}
-
-			// 对每个记录调用 AfterQuery 钩子
-			newObj := newElem.Addr().Interface()
-			if err := s.CallAfterQuery(newObj); err != nil {
-				return err
+		}
+	}
+	assignments = s.touchUpdateTime(assignments, record)
+
+	// 带版本号的记录只更新版本未变的行，并递增版本号
+	where := s.whereExprs()
+	version := s.Schema.VersionField()
+	locked := version != nil && record.IsValid()
+	var current, next int64
+	if locked {
+		// 版本号只对应一行记录，条件中必须包含主键
+		if !byPrimaryKey {
+			pks, ok := s.primaryKeyExprs(record)
+			if !ok {
+				return 0, false, fmt.Errorf("versioned update of %s needs its primary key", s.Schema.Name)
 			}
+			where = append(where[:len(where):len(where)], pks...)
+		}
+		current = integerValue(s.fieldValue(record, version))
+		next = current + 1
+		where = append(where, qsyclause.Eq{Column: version.DBName, Value: current})
+		assignments = assign(assignments, qsyclause.Assignment{Column: version.DBName, Value: next})
+	}
 
-			// Append the new element to the result slice
-			destValue.Elem().Set(reflect.Append(destValue.Elem(), newElem))
+	if assignments, err = s.applySets(assignments); err != nil {
+		return 0, false, err
+	}
+	if len(assignments) == 0 {
+		return 0, false, errors.New("no columns to update")
+	}
+
+	affected, err = s.execUpdate(assignments, where)
+	if err != nil {
+		return 0, false, err
+	}
+	if locked {
+		if affected != 1 {
+			return 0, false, fmt.Errorf("%w: %s with version %d was changed or deleted", ErrStaleObject, s.Schema.Name, current)
+		}
+		if value := s.fieldValue(record, version); value.CanSet() {
+			setInteger(value, next)
+			// 事务回滚后恢复原版本号，重试时不会被误判为过期
+			s.onRollback(func() { setInteger(value, current) })
 		}
+	}
+	// 只写入了记录自己那一行时刷新它的快照；其它更新影响哪些行无法得知，
+	// 丢弃该模型的所有快照，之后的更新写入全部列
+	if record.IsValid() && (byPrimaryKey || locked) {
+		s.refreshSnapshot(record, assignments)
+	} else {
+		s.forgetSnapshots()
+	}
 
-		return rows.Err()
+	// 调用 AfterUpdate 钩子
+	if err := s.CallAfterUpdate(hookTarget); err != nil {
+		return affected, false, err
 	}
+
+	return affected, false, nil
 }
 
-// Update modifies existing records in the database
-func (s *Session) Update(value interface{}, where string, vars ...interface{}) (int64, error) {
-	if s.Schema == nil {
-		return 0, errors.New("schema is nil")
+// primaryKeyExprs returns the conditions matching the row of record by
+// primary key; ok is false when the model has no primary key or record
+// carries a zero key value
+func (s *Session) primaryKeyExprs(record reflect.Value) (pks []qsyclause.Expression, ok bool) {
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) == 0 {
+		return nil, false
 	}
-
-	// 调用 BeforeUpdate 钩子
-	if err := s.CallBeforeUpdate(value); err != nil {
-		return 0, err
+	for _, field := range primaries {
+		pk := s.fieldValue(record, field)
+		if pk.IsZero() {
+			return nil, false
+		}
+		pks = append(pks, qsyclause.Eq{Column: field.DBName, Value: pk.Interface()})
 	}
+	return pks, true
+}
 
-	// 获取字段名和值 - 在调用钩子后获取，这样钩子中的修改会被包含
-	reflectValue := reflect.Indirect(reflect.ValueOf(value))
-	if reflectValue.Kind() != reflect.Struct {
-		return 0, errors.New("value must be a struct")
+// execUpdate runs an UPDATE of assignments restricted by where and returns
+// the affected rows
+func (s *Session) execUpdate(assignments qsyclause.Assignments, where []qsyclause.Expression) (int64, error) {
+	builder := s.newBuilder()
+	updateSql, _ := qsyclause.BuildUpdateTable(s.quotedTable())
+	builder.Set(qsyclause.UPDATE, updateSql)
+	builder.Set(qsyclause.SET, assignments)
+	if len(where) > 0 {
+		builder.Set(qsyclause.WHERE, qsyclause.Where{Exprs: where})
 	}
 
-	fields := make([]string, 0)
-	updateVars := make([]interface{}, 0)
+	// update vars come before where vars
+	sqlStr, sqlVars := builder.Build(qsyclause.UPDATE, qsyclause.SET, qsyclause.WHERE)
+	result, err := s.Raw(sqlStr, sqlVars...).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
 
-	for _, field := range s.Schema.Fields {
+// updateFields returns the fields an Update may write, honouring Select and Omit
+func (s *Session) updateFields() ([]*qsyschema.Field, error) {
+	fields, err := s.selectFields()
+	if err != nil {
+		return nil, err
+	}
+	writable := make([]*qsyschema.Field, 0, len(fields))
+	for _, field := range fields {
 		// 排除自增主键字段
 		if field.IsPrimaryKey && field.IsAutoIncrement {
 			continue
 		}
-		fields = append(fields, field.Name)
-
-		// 使用DbFieldToGo映射找到对应的Go结构体字段名
-		goFieldName, ok := s.Schema.DbFieldToGo[field.Name]
-		if !ok {
-			// 如果映射中没有，尝试直接使用字段名
-			goFieldName = field.Name
-		}
+		writable = append(writable, field)
+	}
+	return writable, nil
+}
 
-		updateVars = append(updateVars, reflectValue.FieldByName(goFieldName).Interface())
+// structAssignments collects the columns and values written from a struct
+func (s *Session) structAssignments(value interface{}) (qsyclause.Assignments, error) {
+	reflectValue, err := s.modelValue(value)
+	if err != nil {
+		return nil, err
 	}
+	fields, err := s.updateFields()
+	if err != nil {
+		return nil, err
+	}
+	assignments := make(qsyclause.Assignments, 0, len(fields))
+	for _, field := range fields {
+		assignments = append(assignments, qsyclause.Assignment{
+			Column: field.DBName,
+			Value:  s.fieldValue(reflectValue, field).Interface(),
+		})
+	}
+	return assignments, nil
+}
 
-	// Build the SQL statement
-	builder := qsyclause.New()
+// mapAssignments collects the columns and values written from a map
+func (s *Session) mapAssignments(values map[string]interface{}) (qsyclause.Assignments, error) {
+	allowed, err := s.updateFields()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	// 排序保证生成的 SQL 稳定
+	sort.Strings(keys)
+
+	assignments := make(qsyclause.Assignments, 0, len(keys))
+	for _, key := range keys {
+		field := s.lookupField(key)
+		if field == nil {
+			return nil, fmt.Errorf("unknown column %s in model %s", key, s.Schema.Name)
+		}
+		if !containsField(allowed, field) {
+			continue
+		}
+		assignments = append(assignments, qsyclause.Assignment{Column: field.DBName, Value: s.resolveColumns(values[key])})
+	}
+	return assignments, nil
+}
 
-	// Create UPDATE clause
-	updateSql, _ := qsyclause.BuildUpdate(s.Schema.GetTableName(), fields)
-	builder.Set(qsyclause.UPDATE, updateSql)
+// applySets merges the columns chained with Set into assignments,
+// replacing a column that is already assigned
+func (s *Session) applySets(assignments qsyclause.Assignments) (qsyclause.Assignments, error) {
+	for _, set := range s.statement.sets {
+		field := s.lookupField(set.Column)
+		if field == nil {
+			return nil, fmt.Errorf("unknown column %s in model %s", set.Column, s.Schema.Name)
+		}
+		set.Column = field.DBName
+		set.Value = s.resolveColumns(set.Value)
+		assignments = assign(assignments, set)
+	}
+	return assignments, nil
+}
 
-	// Add WHERE clause if provided
-	var whereVars []interface{}
-	if where != "" {
-		whereSql, wVars := qsyclause.BuildWhere(where, vars...)
-		whereVars = wVars
-		builder.Set(qsyclause.WHERE, whereSql)
+// resolveColumns maps the columns referenced by an assigned expression,
+// such as qsyclause.Increment("ViewCount", 1), from Go field names to
+// column names; unknown names are kept as written
+func (s *Session) resolveColumns(value interface{}) interface{} {
+	switch v := value.(type) {
+	case qsyclause.Column:
+		if field := s.lookupField(v.Name); field != nil {
+			v.Name = field.DBName
+		}
+		return v
+	case qsyclause.Raw:
+		vars := make([]interface{}, len(v.Vars))
+		for i, arg := range v.Vars {
+			vars[i] = s.resolveColumns(arg)
+		}
+		v.Vars = vars
+		return v
 	}
+	return value
+}
 
-	// Build the query
-	sqlStr, sqlVars := builder.Build(qsyclause.UPDATE, qsyclause.WHERE)
+// assign replaces the assignment of the same column, or appends a
+func assign(assignments qsyclause.Assignments, a qsyclause.Assignment) qsyclause.Assignments {
+	for i := range assignments {
+		if assignments[i].Column == a.Column {
+			assignments[i] = a
+			return assignments
+		}
+	}
+	return append(assignments, a)
+}
 
-	// Execute the query - first add update vars, then where vars
-	allVars := append(updateVars, sqlVars...)
-	if where != "" {
-		allVars = append(allVars, whereVars...)
+// Delete removes records from the database. It takes either conditions,
+// e.g. Delete("Age > ?", 18), combined with any chained Where/Or/Not, or
+// records of the model (struct pointers or slices of structs), which are
+// deleted by primary key with BeforeDelete/AfterDelete run on every record.
+// A condition delete that matches every row is refused with
+// ErrMissingWhereClause unless AllowGlobalUpdate is chained; its hooks run
+// on a new instance of the model. Models with a soft delete field are only marked
+// deleted, see Unscoped and Restore.
+func (s *Session) Delete(conds ...interface{}) (int64, error) {
+	defer s.resetStatement()
+	if s.Schema == nil {
+		return 0, errors.New("schema is nil")
 	}
-	s.Raw(sqlStr, allVars...)
-	result, err := s.Exec()
-	if err != nil {
+	if len(conds) > 0 && s.isRecord(conds[0]) {
+		for _, cond := range conds[1:] {
+			if !s.isRecord(cond) {
+				return 0, errors.New("Delete takes either records or conditions, not both")
+			}
+		}
+		return s.deleteRecords(flattenValues(conds))
+	}
+	if err := s.applyConds(conds); err != nil {
 		return 0, err
 	}
+	if len(s.statement.where) == 0 && !s.statement.allowGlobal {
+		return 0, ErrMissingWhereClause
+	}
+
+	// 没有具体的记录，钩子在新的模型实例上调用
+	hookTarget := s.hookModel()
+	if hookTarget != nil {
+		if err := s.CallBeforeDelete(hookTarget); err != nil {
+			return 0, err
+		}
+	}
 
-	affected, err := result.RowsAffected()
+	affected, err := s.execDelete(s.statement.where)
 	if err != nil {
 		return 0, err
 	}
+	s.forgetSnapshots()
 
-	// 调用 AfterUpdate 钩子
-	if err := s.CallAfterUpdate(value); err != nil {
-		return affected, err
+	// 调用 AfterDelete 钩子
+	if hookTarget != nil {
+		if err := s.CallAfterDelete(hookTarget); err != nil {
+			return affected, err
+		}
 	}
 
 	return affected, nil
 }
 
-// Delete removes records from the database
-func (s *Session) Delete(where string, vars ...interface{}) (int64, error) {
+// DeleteByID removes the records with the given primary keys; slices are
+// expanded, so DeleteByID(1, 2) and DeleteByID([]int{1, 2}) are the same.
+// Hooks run on a new instance of the model as for a condition delete.
+func (s *Session) DeleteByID(ids ...interface{}) (int64, error) {
 	if s.Schema == nil {
 		return 0, errors.New("schema is nil")
 	}
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) != 1 {
+		s.resetStatement()
+		return 0, fmt.Errorf("DeleteByID needs a single primary key, model %s has %d", s.Schema.Name, len(primaries))
+	}
+	values := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		rv := reflect.ValueOf(id)
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+			for i := 0; i < rv.Len(); i++ {
+				values = append(values, rv.Index(i).Interface())
+			}
+			continue
+		}
+		values = append(values, id)
+	}
+	if len(values) == 0 {
+		s.resetStatement()
+		return 0, errors.New("no ids provided")
+	}
+	return s.Where(qsyclause.In{Column: primaries[0].DBName, Values: values}).Delete()
+}
+
+// deleteRecords deletes records by primary key in chunks, running the
+// delete hooks on each record, inside one transaction
+func (s *Session) deleteRecords(records []interface{}) (int64, error) {
+	if err := s.applyConds(nil); err != nil {
+		return 0, err
+	}
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) == 0 {
+		return 0, fmt.Errorf("model %s has no primary key", s.Schema.Name)
+	}
 
-	// 创建一个模型实例来调用钩子（如果有）
-	if s.Schema.Model != nil {
-		if err := s.CallBeforeDelete(s.Schema.Model); err != nil {
+	// 调用 BeforeDelete 钩子
+	for _, record := range records {
+		if err := s.CallBeforeDelete(record); err != nil {
 			return 0, err
 		}
 	}
+	keys := make([][]interface{}, len(records))
+	for i, record := range records {
+		reflectValue, err := s.modelValue(record)
+		if err != nil {
+			return 0, err
+		}
+		zero := true
+		keys[i] = make([]interface{}, len(primaries))
+		for j, field := range primaries {
+			value := s.fieldValue(reflectValue, field)
+			zero = zero && value.IsZero()
+			keys[i][j] = value.Interface()
+		}
+		// 主键为零值说明记录还没有保存过，不能用来定位行
+		if zero {
+			return 0, fmt.Errorf("record of model %s has no primary key value", s.Schema.Name)
+		}
+	}
 
-	// Build the SQL statement
-	builder := qsyclause.New()
-
-	// Create DELETE clause
-	deleteSql, _ := qsyclause.BuildDelete(s.Schema.GetTableName())
-	builder.Set(qsyclause.DELETE, deleteSql)
+	where := s.statement.where
+	chunkSize := s.chunkSize(len(primaries))
+	var affected int64
+	remove := func(s *Session) error {
+		for start := 0; start < len(records); start += chunkSize {
+			end := start + chunkSize
+			if end > len(records) {
+				end = len(records)
+			}
+			conditions := append([]qsyclause.Expression{primaryKeyCondition(primaries, keys[start:end])}, where...)
+			n, err := s.execDelete(conditions)
+			if err != nil {
+				return err
+			}
+			affected += n
+			for _, record := range records[start:end] {
+				reflectValue, _ := s.modelValue(record)
+				s.forgetSnapshot(reflectValue)
+			}
 
-	// Add WHERE clause if provided
-	var whereVars []interface{}
-	if where != "" {
-		whereSql, wVars := qsyclause.BuildWhere(where, vars...)
-		whereVars = wVars
-		builder.Set(qsyclause.WHERE, whereSql)
+			// 调用 AfterDelete 钩子
+			for _, record := range records[start:end] {
+				if err := s.CallAfterDelete(record); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
 	}
 
-	// Build the query
-	sqlStr, sqlVars := builder.Build(qsyclause.DELETE, qsyclause.WHERE)
+	var err error
+	if len(records) > chunkSize && s.tx == nil {
+		err = s.Transaction(remove)
+	} else {
+		err = remove(s)
+	}
+	return affected, err
+}
 
-	// Execute the query with where vars
-	allVars := sqlVars
-	if where != "" {
-		allVars = append(allVars, whereVars...)
+// execDelete runs a DELETE restricted by where and returns the affected rows;
+// soft-deleted models get an UPDATE of the soft delete field instead
+func (s *Session) execDelete(where []qsyclause.Expression) (int64, error) {
+	if field := s.Schema.SoftDeleteField; field != nil && !s.statement.unscoped {
+		where = append(where[:len(where):len(where)], qsyclause.Eq{Column: field.DBName, Value: aliveValue(field)})
+		return s.execUpdate(qsyclause.Assignments{{Column: field.DBName, Value: s.deletedValue(field)}}, where)
 	}
-	s.Raw(sqlStr, allVars...)
-	result, err := s.Exec()
-	if err != nil {
-		return 0, err
+	builder := s.newBuilder()
+	deleteSql, _ := qsyclause.BuildDelete(s.quotedTable())
+	builder.Set(qsyclause.DELETE, deleteSql)
+	if len(where) > 0 {
+		builder.Set(qsyclause.WHERE, qsyclause.Where{Exprs: where})
 	}
 
-	affected, err := result.RowsAffected()
+	sqlStr, sqlVars := builder.Build(qsyclause.DELETE, qsyclause.WHERE)
+	result, err := s.Raw(sqlStr, sqlVars...).Exec()
 	if err != nil {
 		return 0, err
 	}
+	return result.RowsAffected()
+}
 
-	// 调用 AfterDelete 钩子
-	if s.Schema.Model != nil {
-		if err := s.CallAfterDelete(s.Schema.Model); err != nil {
-			return affected, err
+// hookModel returns a new instance of the session model for the hooks of
+// operations without records; Schema.Model itself may be shared by every
+// session using the schema (see Typed), so hooks must not modify it
+func (s *Session) hookModel() interface{} {
+	if s.Schema.Model == nil {
+		return nil
+	}
+	return reflect.New(reflect.Indirect(reflect.ValueOf(s.Schema.Model)).Type()).Interface()
+}
+
+// isRecord reports whether value is a record of the session model or a
+// slice of them, as opposed to a condition
+func (s *Session) isRecord(value interface{}) bool {
+	if value == nil || s.Schema.Model == nil {
+		return false
+	}
+	modelType := reflect.Indirect(reflect.ValueOf(s.Schema.Model)).Type()
+	typ := reflect.TypeOf(value)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
 		}
 	}
+	return typ == modelType
+}
 
-	return affected, nil
+// primaryKeyCondition matches the rows whose primary key is one of keys,
+// "pk IN (...)" for a single key or "(a = ? AND b = ?) OR ..." otherwise
+func primaryKeyCondition(primaries []*qsyschema.Field, keys [][]interface{}) qsyclause.Expression {
+	if len(primaries) == 1 {
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = key[0]
+		}
+		return qsyclause.In{Column: primaries[0].DBName, Values: values}
+	}
+	rows := make([]qsyclause.Expression, len(keys))
+	for i, key := range keys {
+		eqs := make([]qsyclause.Expression, len(primaries))
+		for j, field := range primaries {
+			eqs[j] = qsyclause.Eq{Column: field.DBName, Value: key[j]}
+		}
+		rows[i] = qsyclause.And(eqs...)
+	}
+	return qsyclause.Or(rows...)
+}
+
+// chunkSize returns how many rows fit in one statement when each takes
+// varsPerRow bind vars, leaving room for the vars of the chained conditions
+func (s *Session) chunkSize(varsPerRow int) int {
+	limit := maxInsertVars
+	if where := s.whereExprs(); len(where) > 0 {
+		_, whereVars := qsyclause.Build(s.dialect, qsyclause.And(where...))
+		limit -= len(whereVars)
+	}
+	if size := limit / varsPerRow; size > 0 {
+		return size
+	}
+	return 1
 }
 
 // Count returns the number of records that match the condition
-func (s *Session) Count(where string, vars ...interface{}) (int64, error) {
+func (s *Session) Count(conds ...interface{}) (int64, error) {
+	defer s.resetStatement()
 	if s.Schema == nil {
 		return 0, errors.New("schema is nil")
 	}
+	if err := s.applyConds(conds); err != nil {
+		return 0, err
+	}
 
 	// Build the SQL statement
-	builder := qsyclause.New()
-
-	// Create COUNT query
-	countSql := "SELECT COUNT(*) FROM " + s.Schema.GetTableName()
+	builder := s.newBuilder()
+	countSql := "SELECT COUNT(*) FROM " + s.quotedTable()
 	builder.Set(qsyclause.COUNT, countSql)
+	s.buildWhere(builder)
 
-	// Add WHERE clause if provided
-	var whereVars []interface{}
-	if where != "" {
-		whereSql, wVars := qsyclause.BuildWhere(where, vars...)
-		whereVars = wVars
-		builder.Set(qsyclause.WHERE, whereSql)
-	}
-
-	// Build the query
 	sqlStr, sqlVars := builder.Build(qsyclause.COUNT, qsyclause.WHERE)
-
-	// Execute the query with where vars
-	allVars := sqlVars
-	if where != "" {
-		allVars = append(allVars, whereVars...)
-	}
-	s.Raw(sqlStr, allVars...)
+	s.Raw(sqlStr, sqlVars...)
 
 	var count int64
-	row := s.QueryRow()
-	if err := row.Scan(&count); err != nil {
+	ctx, cancel := s.statementContext()
+	defer cancel()
+	if err := s.queryRowContext(ctx).Scan(&count); err != nil {
 		return 0, err
 	}
 
 	return count, nil
 }
+
+// fieldValue returns the struct field of v that backs the schema field,
+// following its index path through embedded structs. Nil embedded pointers
+// are allocated when v is addressable; otherwise the zero value is returned.
+func (s *Session) fieldValue(v reflect.Value, field *qsyschema.Field) reflect.Value {
+	if len(field.StructIndex) == 0 {
+		return v.FieldByName(field.Name)
+	}
+	for i, x := range field.StructIndex {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Zero(field.GoType)
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -2,8 +2,11 @@ package qsysession_test
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"qsyorm/qsyclause"
 	"qsyorm/qsydialect"
 	"qsyorm/qsylog"
 	"qsyorm/qsysession"
@@ -158,3 +161,311 @@ func TestCRUD(t *testing.T) {
 	t.Log("删除操作成功")
 	t.Log("所有测试通过!")
 }
+
+// BatchUser 统计钩子调用次数
+type BatchUser struct {
+	ID    int    `qsy:"primarykey;autoincrement"`
+	Name  string `qsy:"unique"`
+	Age   int
+	hooks int
+}
+
+func (u *BatchUser) BeforeInsert() error {
+	u.hooks++
+	return nil
+}
+
+func (u *BatchUser) AfterInsert() error {
+	u.hooks++
+	return nil
+}
+
+func TestBatchInsert(t *testing.T) {
+	s := newTestSession(t, &BatchUser{})
+
+	users := make([]BatchUser, 1200)
+	for i := range users {
+		users[i] = BatchUser{Name: fmt.Sprintf("user%d", i), Age: i}
+	}
+
+	id, err := s.Insert(users)
+	if err != nil {
+		t.Fatal("批量插入失败:", err)
+	}
+	if id != 1200 {
+		t.Fatalf("期望最后插入的 ID 为1200，实际为%d", id)
+	}
+	for i := range users {
+		if users[i].hooks != 2 {
+			t.Fatalf("第%d条记录钩子调用次数为%d", i, users[i].hooks)
+		}
+		if users[i].ID != i+1 {
+			t.Fatalf("第%d条记录回填的 ID 为%d", i, users[i].ID)
+		}
+	}
+
+	single := &BatchUser{Name: "single"}
+	if _, err := s.Insert(single); err != nil || single.ID != 1201 {
+		t.Fatalf("单条插入回填 ID 错误: %d, %v", single.ID, err)
+	}
+	if _, err := s.Delete("ID = ?", single.ID); err != nil {
+		t.Fatal("删除失败:", err)
+	}
+
+	count, err := s.Count()
+	if err != nil || count != 1200 {
+		t.Fatalf("批量插入后计数错误: %d, %v", count, err)
+	}
+
+	var last BatchUser
+	if err := s.Last(&last); err != nil || last.Name != "user1199" || last.Age != 1199 {
+		t.Fatalf("批量插入数据错误: %v, %v", last, err)
+	}
+
+	// 任一分块失败时整批回滚
+	more := make([]*BatchUser, 600)
+	for i := range more {
+		more[i] = &BatchUser{Name: fmt.Sprintf("more%d", i)}
+	}
+	more[550].Name = "user0"
+	if _, err := s.Insert(more); err == nil {
+		t.Fatal("期望唯一约束冲突报错")
+	}
+	// 回滚后已回填的 ID 被清零，修正后重试会重新分配
+	if more[0].ID != 0 {
+		t.Fatalf("回滚后 ID 应被清零，实际为%d", more[0].ID)
+	}
+	more[550].Name = "more550"
+	if _, err := s.Insert(more); err != nil {
+		t.Fatal("重试批量插入失败:", err)
+	}
+	var first BatchUser
+	if err := s.Get(&first, more[0].ID); err != nil || first.Name != "more0" {
+		t.Fatalf("重试后回填的 ID 错误: %d, %v, %v", more[0].ID, first, err)
+	}
+	if _, err := s.Delete("Name LIKE ?", "more%"); err != nil {
+		t.Fatal("删除失败:", err)
+	}
+	if _, err := s.Insert(&BatchUser{Name: "a"}, &TestUser{Name: "c"}); err == nil {
+		t.Fatal("期望类型不匹配报错")
+	}
+	count, _ = s.Count()
+	if count != 1200 {
+		t.Fatalf("失败的批量插入不应写入数据，实际记录数%d", count)
+	}
+}
+
+func TestInsertMixedKeys(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+
+	// 只有部分记录给出自增主键时，按是否给出主键分组插入
+	a, b := &TestUser{ID: 100, Name: "a"}, &TestUser{Name: "b"}
+	if _, err := s.Insert(a, b); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if a.ID != 100 || b.ID != 101 {
+		t.Fatalf("主键错误: %d, %d", a.ID, b.ID)
+	}
+	for _, u := range []*TestUser{a, b} {
+		var got TestUser
+		if err := s.Get(&got, u.ID); err != nil || got.Name != u.Name {
+			t.Fatalf("记录与主键不符: %v, %v", got, err)
+		}
+	}
+}
+
+func TestPartialUpdate(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	// map 更新只写入给出的列，零值也会写入
+	affected, err := s.Where("Name = ?", "张三").Update(map[string]interface{}{"Age": 0})
+	if err != nil || affected != 1 {
+		t.Fatalf("map 更新失败: %d, %v", affected, err)
+	}
+	var user TestUser
+	if err := s.First(&user, "Name = ?", "张三"); err != nil || user.Age != 0 {
+		t.Fatalf("map 更新结果错误: %v, %v", user, err)
+	}
+
+	// Select 只写入选中的列，其它字段即使是零值也不会覆盖
+	if _, err := s.Select("Age").Where("Name = ?", "李四").Update(&TestUser{Age: 31}); err != nil {
+		t.Fatal("Select 更新失败:", err)
+	}
+	user = TestUser{}
+	if err := s.First(&user, "Age = ?", 31); err != nil || user.Name != "李四" {
+		t.Fatalf("Select 更新结果错误: %v, %v", user, err)
+	}
+
+	// Omit 排除指定列
+	if _, err := s.Omit("Name").Where("ID = ?", user.ID).Update(&TestUser{Name: "", Age: 32}); err != nil {
+		t.Fatal("Omit 更新失败:", err)
+	}
+	user = TestUser{}
+	if err := s.Get(&user, 2); err != nil || user.Name != "李四" || user.Age != 32 {
+		t.Fatalf("Omit 更新结果错误: %v, %v", user, err)
+	}
+
+	// Omit 同样作用于 Find
+	var users []TestUser
+	if err := s.Omit("Age").Find(&users, "ID = ?", 2); err != nil || len(users) != 1 || users[0].Age != 0 {
+		t.Fatalf("Omit 查询结果错误: %v, %v", users, err)
+	}
+
+	if _, err := s.Update(map[string]interface{}{"Missing": 1}); err == nil {
+		t.Fatal("期望未知列报错")
+	}
+	if _, err := s.Omit("Name", "Age").Update(&TestUser{ID: 4}); err == nil {
+		t.Fatal("期望没有可更新列时报错")
+	}
+}
+
+func TestUpdateByPrimaryKey(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	// 没有条件的结构体只更新主键对应的行，无论是否由会话加载
+	var loaded TestUser
+	if err := s.First(&loaded, "Name = ?", "张三"); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	loaded.Age = 26
+	if affected, err := s.Update(&loaded); err != nil || affected != 1 {
+		t.Fatalf("更新已加载记录失败: %d, %v", affected, err)
+	}
+	if affected, err := s.Update(&TestUser{ID: 2, Name: "李四", Age: 31}); err != nil || affected != 1 {
+		t.Fatalf("更新未加载记录失败: %d, %v", affected, err)
+	}
+	count, err := s.Count("Age IN ?", []int{26, 31, 35, 40})
+	if err != nil || count != 4 {
+		t.Fatalf("其它行不应被修改: %d, %v", count, err)
+	}
+
+	// 没有主键也没有条件时拒绝更新
+	if _, err := s.Update(&TestUser{Name: "x"}); !errors.Is(err, qsysession.ErrMissingWhereClause) {
+		t.Fatalf("期望 ErrMissingWhereClause，实际为 %v", err)
+	}
+	if count, err := s.Count("Name = ?", "x"); err != nil || count != 0 {
+		t.Fatalf("拒绝的更新不应写入: %d, %v", count, err)
+	}
+}
+
+func TestGlobalUpdate(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	// 没有条件的 map、Set 和无主键结构体更新会修改所有行，需要 AllowGlobalUpdate
+	if _, err := s.Update(map[string]interface{}{"Age": 1}); !errors.Is(err, qsysession.ErrMissingWhereClause) {
+		t.Fatalf("map 更新期望 ErrMissingWhereClause，实际为 %v", err)
+	}
+	if _, err := s.Set("Age", qsyclause.Increment("Age", 1)).Update(nil); !errors.Is(err, qsysession.ErrMissingWhereClause) {
+		t.Fatalf("Set 更新期望 ErrMissingWhereClause，实际为 %v", err)
+	}
+	if _, err := s.Select("Age").Update(&TestUser{Age: 1}); !errors.Is(err, qsysession.ErrMissingWhereClause) {
+		t.Fatalf("结构体更新期望 ErrMissingWhereClause，实际为 %v", err)
+	}
+	if count, err := s.Count("Age = ?", 1); err != nil || count != 0 {
+		t.Fatalf("拒绝的更新不应写入: %d, %v", count, err)
+	}
+
+	affected, err := s.AllowGlobalUpdate().Update(map[string]interface{}{"Age": 1})
+	if err != nil || affected != 4 {
+		t.Fatalf("全表更新错误: %d, %v", affected, err)
+	}
+}
+
+func TestUpdateExpression(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	// map 中的表达式直接写入 SET 子句
+	affected, err := s.Where("Name = ?", "张三").Update(map[string]interface{}{"Age": qsyclause.Increment("Age", 5)})
+	if err != nil || affected != 1 {
+		t.Fatalf("表达式更新失败: %d, %v", affected, err)
+	}
+	var user TestUser
+	if err := s.First(&user, "Name = ?", "张三"); err != nil || user.Age != 30 {
+		t.Fatalf("Increment 结果错误: %v, %v", user, err)
+	}
+
+	// 只用 Set 更新
+	affected, err = s.Set("Age", qsyclause.Decrement("Age", 10)).Where("Age >= ?", 35).Update(nil)
+	if err != nil || affected != 2 {
+		t.Fatalf("Set 更新失败: %d, %v", affected, err)
+	}
+	count, err := s.Count("Age IN ?", []int{25, 30})
+	if err != nil || count != 4 {
+		t.Fatalf("Decrement 结果错误: %d, %v", count, err)
+	}
+
+	// Set 覆盖结构体中的同名列
+	_, err = s.Set("Name", qsyclause.Expr("? || ?", qsyclause.Column{Name: "Name"}, "!")).
+		Where("ID = ?", 1).Update(&TestUser{Name: "ignored", Age: 20})
+	if err != nil {
+		t.Fatal("Set 覆盖更新失败:", err)
+	}
+	user = TestUser{}
+	if err := s.Get(&user, 1); err != nil || user.Name != "张三!" || user.Age != 20 {
+		t.Fatalf("Expr 结果错误: %v, %v", user, err)
+	}
+
+	if _, err := s.Update(nil); err == nil {
+		t.Fatal("期望没有可更新列时报错")
+	}
+}
+
+// DeleteUser 记录删除钩子看到的实例
+type DeleteUser struct {
+	ID      int `qsy:"primarykey;autoincrement"`
+	Name    string
+	deleted bool
+}
+
+func (u *DeleteUser) AfterDelete() error {
+	u.deleted = true
+	return nil
+}
+
+func TestDeleteRecords(t *testing.T) {
+	s := newTestSession(t, &DeleteUser{})
+	users := make([]DeleteUser, 6)
+	for i := range users {
+		users[i] = DeleteUser{Name: fmt.Sprintf("user%d", i)}
+	}
+	if _, err := s.Insert(users); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	// 按记录删除，钩子在真实实例上调用
+	affected, err := s.Delete(&users[0])
+	if err != nil || affected != 1 || !users[0].deleted {
+		t.Fatalf("按记录删除错误: %d, %v, %+v", affected, err, users[0])
+	}
+
+	batch := users[1:3]
+	affected, err = s.Delete(&batch)
+	if err != nil || affected != 2 || !batch[0].deleted || !batch[1].deleted {
+		t.Fatalf("按切片删除错误: %d, %v, %+v", affected, err, batch)
+	}
+
+	affected, err = s.DeleteByID([]int{users[3].ID, users[4].ID})
+	if err != nil || affected != 2 {
+		t.Fatalf("按主键删除错误: %d, %v", affected, err)
+	}
+
+	if _, err := s.Delete(&DeleteUser{}); err == nil {
+		t.Fatal("期望没有主键的记录报错")
+	}
+	if _, err := s.Delete(&users[5], "Name = ?", "x"); err == nil {
+		t.Fatal("期望记录与条件混用报错")
+	}
+
+	// 没有条件的删除被拒绝
+	if _, err := s.Delete(); !errors.Is(err, qsysession.ErrMissingWhereClause) {
+		t.Fatalf("期望 ErrMissingWhereClause，实际为 %v", err)
+	}
+	affected, err = s.AllowGlobalUpdate().Delete()
+	if err != nil || affected != 1 {
+		t.Fatalf("全表删除错误: %d, %v", affected, err)
+	}
+}
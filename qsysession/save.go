@@ -0,0 +1,119 @@
+package qsysession
+
+import (
+	"errors"
+	"fmt"
+	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
+)
+
+// OnConflict sets the ON CONFLICT clause used by the next Insert or Upsert
+func (s *Session) OnConflict(conflict qsyclause.OnConflict) *Session {
+	s.statement.onConflict = &conflict
+	return s
+}
+
+// Upsert inserts values with INSERT ... ON CONFLICT. Unless OnConflict was
+// chained, the conflict target is the primary key, or the first unique
+// field when the primary key is auto-generated, and every other inserted
+// column is overwritten except the creation time and the version. It returns the number of affected rows; generated
+// ids are not written back since conflicting rows keep their existing id.
+func (s *Session) Upsert(values ...interface{}) (int64, error) {
+	if s.Schema == nil {
+		s.resetStatement()
+		return 0, errors.New("schema is nil")
+	}
+	if s.statement.onConflict == nil {
+		conflict, err := s.defaultConflict()
+		if err != nil {
+			s.resetStatement()
+			return 0, err
+		}
+		s.statement.onConflict = conflict
+	}
+	_, affected, err := s.insertRecords(values)
+	return affected, err
+}
+
+// defaultConflict builds the ON CONFLICT clause Upsert uses by default
+func (s *Session) defaultConflict() (*qsyclause.OnConflict, error) {
+	var target []*qsyschema.Field
+	if s.Schema.AutoIncrementField() == nil {
+		target = s.Schema.PrimaryFields()
+	}
+	if len(target) == 0 {
+		for _, field := range s.Schema.Fields {
+			if field.Unique {
+				target = []*qsyschema.Field{field}
+				break
+			}
+		}
+	}
+	if len(target) == 0 {
+		return nil, fmt.Errorf("model %s has no primary key or unique field to upsert on", s.Schema.Name)
+	}
+
+	conflict := &qsyclause.OnConflict{}
+	for _, field := range target {
+		conflict.Columns = append(conflict.Columns, field.DBName)
+	}
+	for _, field := range s.Schema.Fields {
+		// 冲突时保留原有的创建时间和版本号
+		if field.IsPrimaryKey && field.IsAutoIncrement || containsField(target, field) ||
+			field.AutoCreateTime != qsyschema.AutoTimeNone || field.IsVersion {
+			continue
+		}
+		conflict.DoUpdates = append(conflict.DoUpdates, field.DBName)
+	}
+	return conflict, nil
+}
+
+// Save inserts value when its primary key is zero and otherwise updates the
+// row with that primary key, inserting it if no such row exists.
+// It returns the number of affected rows. A versioned record whose row was
+// changed or deleted is reported as ErrStaleObject rather than re-inserted.
+// A record loaded by this session writes only its changed columns.
+// A soft-deleted row is updated in place and stays deleted unless value
+// clears its deleted marker.
+func (s *Session) Save(value interface{}) (int64, error) {
+	table := s.statement.table
+	if s.Schema == nil {
+		s.resetStatement()
+		return 0, errors.New("schema is nil")
+	}
+	reflectValue, err := s.modelValue(value)
+	if err != nil {
+		s.resetStatement()
+		return 0, err
+	}
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) == 0 {
+		s.resetStatement()
+		return 0, fmt.Errorf("model %s has no primary key", s.Schema.Name)
+	}
+
+	for _, field := range primaries {
+		if s.fieldValue(reflectValue, field).IsZero() {
+			s.resetStatement()
+			return s.insertOne(value, table)
+		}
+	}
+
+	// Save 总是按主键更新，忽略之前链式设置的条件，没有条件的结构体由 Update 按主键定位；
+	// 已软删除的行同样按主键更新，而不是再插入一行
+	s.statement.where = nil
+	s.statement.unscoped = true
+	affected, skipped, err := s.update(value, nil)
+	if err != nil || affected > 0 || skipped {
+		return affected, err
+	}
+	return s.insertOne(value, table)
+}
+
+// insertOne inserts value into table, "" being the model's table
+func (s *Session) insertOne(value interface{}, table string) (int64, error) {
+	if _, err := s.Table(table).Insert(value); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
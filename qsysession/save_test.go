@@ -0,0 +1,141 @@
+package qsysession_test
+
+import (
+	"qsyorm/qsyclause"
+	"testing"
+	"time"
+)
+
+// Account 以邮箱作为唯一键
+type Account struct {
+	ID    int    `qsy:"primarykey;autoincrement"`
+	Email string `qsy:"unique"`
+	Name  string
+	Score int
+}
+
+// Setting 使用非自增的字符串主键
+type Setting struct {
+	Key   string `qsy:"primarykey"`
+	Value string
+}
+
+func TestSave(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+
+	user := &TestUser{Name: "张三", Age: 25}
+	if n, err := s.Save(user); err != nil || n != 1 || user.ID != 1 {
+		t.Fatalf("Save 插入失败: %d, %v, %v", n, user, err)
+	}
+
+	user.Age = 26
+	if n, err := s.Where("Age > ?", 100).Save(user); err != nil || n != 1 {
+		t.Fatalf("Save 更新失败: %d, %v", n, err)
+	}
+	var got TestUser
+	if err := s.Get(&got, 1); err != nil || got.Age != 26 {
+		t.Fatalf("Save 更新结果错误: %v, %v", got, err)
+	}
+	if n, _ := s.Count(); n != 1 {
+		t.Fatalf("Save 不应插入新记录，实际记录数%d", n)
+	}
+
+	// 自增主键非零但记录不存在时按给出的主键插入
+	missing := &TestUser{ID: 42, Name: "李四", Age: 30}
+	if n, err := s.Save(missing); err != nil || n != 1 || missing.ID != 42 {
+		t.Fatalf("Save 插入指定主键失败: %d, %v, %v", n, missing, err)
+	}
+	if err := s.Get(&got, 42); err != nil || got.Name != "李四" {
+		t.Fatalf("Save 应按给出的主键插入: %v, %v", got, err)
+	}
+
+	// 主键非零但记录不存在时插入
+	settings := newTestSession(t, &Setting{})
+	if n, err := settings.Save(&Setting{Key: "theme", Value: "dark"}); err != nil || n != 1 {
+		t.Fatalf("Save 插入字符串主键失败: %d, %v", n, err)
+	}
+	if n, err := settings.Save(&Setting{Key: "theme", Value: "light"}); err != nil || n != 1 {
+		t.Fatalf("Save 更新字符串主键失败: %d, %v", n, err)
+	}
+	var setting Setting
+	if err := settings.Get(&setting, "theme"); err != nil || setting.Value != "light" {
+		t.Fatalf("Save 结果错误: %v, %v", setting, err)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	s := newTestSession(t, &Account{})
+
+	if _, err := s.Upsert([]Account{
+		{Email: "a@x.com", Name: "A", Score: 1},
+		{Email: "b@x.com", Name: "B", Score: 2},
+	}); err != nil {
+		t.Fatal("Upsert 插入失败:", err)
+	}
+
+	// 默认以唯一字段为冲突目标，更新其余列
+	if _, err := s.Upsert(&Account{Email: "a@x.com", Name: "A2", Score: 10}, &Account{Email: "c@x.com", Name: "C"}); err != nil {
+		t.Fatal("Upsert 更新失败:", err)
+	}
+	var accounts []Account
+	if err := s.Order("ID").Find(&accounts); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	if len(accounts) != 3 || accounts[0].Name != "A2" || accounts[0].Score != 10 || accounts[0].ID != 1 {
+		t.Fatalf("Upsert 结果错误: %v", accounts)
+	}
+
+	// DO NOTHING 保留已有记录
+	if _, err := s.OnConflict(qsyclause.OnConflict{Columns: []string{"Email"}, DoNothing: true}).
+		Upsert(&Account{Email: "b@x.com", Name: "ignored"}); err != nil {
+		t.Fatal("Upsert DO NOTHING 失败:", err)
+	}
+	var b Account
+	if err := s.First(&b, "Email = ?", "b@x.com"); err != nil || b.Name != "B" {
+		t.Fatalf("DO NOTHING 结果错误: %v, %v", b, err)
+	}
+
+	// 只更新指定列
+	if _, err := s.OnConflict(qsyclause.OnConflict{Columns: []string{"Email"}, DoUpdates: []string{"Score"}}).
+		Insert(&Account{Email: "b@x.com", Name: "ignored", Score: 20}); err != nil {
+		t.Fatal("Insert ON CONFLICT 失败:", err)
+	}
+	b = Account{}
+	if err := s.First(&b, "Email = ?", "b@x.com"); err != nil || b.Name != "B" || b.Score != 20 {
+		t.Fatalf("DO UPDATE 指定列结果错误: %v, %v", b, err)
+	}
+
+	users := newTestSession(t, &TestUser{})
+	if _, err := users.Upsert(&TestUser{Name: "x"}); err == nil {
+		t.Fatal("期望没有冲突目标时报错")
+	}
+}
+
+// Profile 带创建时间和版本号
+type Profile struct {
+	Handle    string `qsy:"primarykey"`
+	Bio       string
+	CreatedAt time.Time
+	Version   int `qsy:"version"`
+}
+
+func TestUpsertKeepsCreatedAt(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestSession(t, &Profile{}).WithClock(func() time.Time { return now })
+	if _, err := s.Upsert(&Profile{Handle: "ann", Bio: "v1"}); err != nil {
+		t.Fatal("Upsert 插入失败:", err)
+	}
+
+	// 冲突更新不覆盖创建时间和版本号
+	now = now.Add(time.Hour)
+	if _, err := s.Upsert(&Profile{Handle: "ann", Bio: "v2", Version: 7}); err != nil {
+		t.Fatal("Upsert 更新失败:", err)
+	}
+	var profile Profile
+	if err := s.Get(&profile, "ann"); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	if profile.Bio != "v2" || !profile.CreatedAt.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) || profile.Version != 1 {
+		t.Fatalf("Upsert 结果错误: %+v", profile)
+	}
+}
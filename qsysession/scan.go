@@ -0,0 +1,184 @@
+package qsysession
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"qsyorm/qsyschema"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// Scan runs the SQL collected by Raw and scans the result into dest, which
+// may point to a struct, a slice of structs, a map[string]interface{},
+// a slice of such maps, a scalar, or a slice of scalars for single-column
+// queries. Struct fields are matched to columns by their qsy "name" tag or,
+// case-insensitively, by field name; unmatched columns are skipped.
+// Pointing dest at a single struct, map or scalar returns ErrRecordNotFound
+// when the query yields no row.
+func (s *Session) Scan(dest interface{}) error {
+	ctx, cancel := s.statementContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return scanRows(rows, dest)
+}
+
+// Pluck reads a single column of the current model into dest, a pointer to a
+// slice of scalars, honouring the chained conditions and ordering
+func (s *Session) Pluck(column string, dest interface{}, conds ...interface{}) error {
+	defer s.resetStatement()
+	if s.Schema == nil {
+		return errors.New("schema is nil")
+	}
+	if err := s.applyConds(conds); err != nil {
+		return err
+	}
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Slice {
+		return errors.New("dest must be a pointer to slice")
+	}
+
+	s.statement.selects = []string{column}
+	_, sqlStr, sqlVars, err := s.buildSelect()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := s.statementContext()
+	defer cancel()
+	rows, err := s.Raw(sqlStr, sqlVars...).queryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return scanRows(rows, dest)
+}
+
+// scanRows scans every row (or the first row for non-slice targets) into dest
+func scanRows(rows *sql.Rows, dest interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		return errors.New("dest must be a non-nil pointer")
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	target := destValue.Elem()
+	if target.Kind() == reflect.Slice && target.Type().Elem().Kind() != reflect.Uint8 {
+		elemType := target.Type().Elem()
+		isPtr := elemType.Kind() == reflect.Ptr
+		if isPtr {
+			elemType = elemType.Elem()
+		}
+		target.Set(reflect.MakeSlice(target.Type(), 0, 0))
+		for rows.Next() {
+			elem := reflect.New(elemType).Elem()
+			if err := scanInto(rows, columns, elem); err != nil {
+				return err
+			}
+			if isPtr {
+				elem = elem.Addr()
+			}
+			target.Set(reflect.Append(target, elem))
+		}
+		return rows.Err()
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrRecordNotFound
+	}
+	return scanInto(rows, columns, target)
+}
+
+// scanInto scans the current row into v, an addressable struct, map or scalar
+func scanInto(rows *sql.Rows, columns []string, v reflect.Value) error {
+	switch {
+	case v.Kind() == reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.Interface {
+			return fmt.Errorf("unsupported map type %s, want map[string]interface{}", v.Type())
+		}
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(columns)))
+		}
+		for i, column := range columns {
+			value := values[i]
+			// 文本列在 SQLite 驱动中可能以 []byte 返回，转换为字符串便于使用
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			v.SetMapIndex(reflect.ValueOf(column), reflect.ValueOf(&value).Elem())
+		}
+		return nil
+
+	case v.Kind() == reflect.Struct && v.Type() != timeType && !reflect.PointerTo(v.Type()).Implements(scannerType):
+		fields := structColumns(v.Type())
+		ptrs := make([]interface{}, len(columns))
+		for i, column := range columns {
+			if index, ok := fields[strings.ToLower(column)]; ok {
+				ptrs[i] = v.FieldByIndex(index).Addr().Interface()
+			} else {
+				ptrs[i] = new(interface{})
+			}
+		}
+		return rows.Scan(ptrs...)
+
+	default:
+		if len(columns) != 1 {
+			return fmt.Errorf("cannot scan %d columns into %s", len(columns), v.Type())
+		}
+		return rows.Scan(v.Addr().Interface())
+	}
+}
+
+// structColumns maps lower-cased column names to field index paths of t,
+// using the qsy name tag when present, also matching snake_case columns,
+// and descending into anonymous structs and fields tagged embedded
+func structColumns(t reflect.Type) map[string][]int {
+	columns := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tags := qsyschema.ParseTag(field.Tag.Get("qsy"))
+		if _, embedded := tags["embedded"]; (field.Anonymous || embedded) && field.Type.Kind() == reflect.Struct {
+			prefix := strings.ToLower(tags["embeddedPrefix"])
+			for name, index := range structColumns(field.Type) {
+				if _, ok := columns[prefix+name]; !ok {
+					columns[prefix+name] = append([]int{i}, index...)
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		columns[strings.ToLower(field.Name)] = []int{i}
+		if snake := qsyschema.ToSnakeCase(field.Name); columns[snake] == nil {
+			columns[snake] = []int{i}
+		}
+		if name := tags["name"]; name != "" {
+			columns[strings.ToLower(name)] = []int{i}
+		}
+	}
+	return columns
+}
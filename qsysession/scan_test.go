@@ -0,0 +1,77 @@
+package qsysession_test
+
+import (
+	"errors"
+	"qsyorm/qsysession"
+	"testing"
+)
+
+// AgeReport 是一个不对应任何表的 DTO
+type AgeReport struct {
+	Label string `qsy:"name:label"`
+	Total int
+}
+
+func TestScan(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	var reports []AgeReport
+	err := s.Raw("SELECT CASE WHEN Age < 35 THEN 'young' ELSE 'old' END AS label, COUNT(*) AS total, 1 AS extra FROM testuser GROUP BY label ORDER BY label").Scan(&reports)
+	if err != nil {
+		t.Fatal("扫描结构体切片失败:", err)
+	}
+	if len(reports) != 2 || reports[0] != (AgeReport{"old", 2}) || reports[1] != (AgeReport{"young", 2}) {
+		t.Fatalf("扫描结构体切片结果错误: %v", reports)
+	}
+
+	var user TestUser
+	if err := s.Raw("SELECT * FROM testuser WHERE Name = ?", "李四").Scan(&user); err != nil || user.Age != 30 {
+		t.Fatalf("扫描结构体失败: %v, %v", user, err)
+	}
+	if err := s.Raw("SELECT * FROM testuser WHERE Age > ?", 100).Scan(&user); !errors.Is(err, qsysession.ErrRecordNotFound) {
+		t.Fatalf("期望 ErrRecordNotFound，实际 %v", err)
+	}
+
+	var rows []map[string]interface{}
+	if err := s.Raw("SELECT Name, Age FROM testuser ORDER BY Age LIMIT 2").Scan(&rows); err != nil {
+		t.Fatal("扫描 map 切片失败:", err)
+	}
+	if len(rows) != 2 || rows[0]["Name"] != "张三" || rows[1]["Age"] != int64(30) {
+		t.Fatalf("扫描 map 切片结果错误: %v", rows)
+	}
+
+	var names []string
+	if err := s.Raw("SELECT Name FROM testuser ORDER BY Age DESC").Scan(&names); err != nil || len(names) != 4 || names[0] != "赵六" {
+		t.Fatalf("扫描单列失败: %v, %v", names, err)
+	}
+
+	var total int
+	if err := s.Raw("SELECT SUM(Age) FROM testuser").Scan(&total); err != nil || total != 130 {
+		t.Fatalf("扫描标量失败: %d, %v", total, err)
+	}
+	if err := s.Raw("SELECT Name, Age FROM testuser").Scan(&names); err == nil {
+		t.Fatal("期望多列扫描到标量切片报错")
+	}
+}
+
+func TestPluck(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	var ages []int
+	if err := s.Where("Age > ?", 25).Order("Age DESC").Pluck("Age", &ages); err != nil {
+		t.Fatal("Pluck 失败:", err)
+	}
+	if len(ages) != 3 || ages[0] != 40 || ages[2] != 30 {
+		t.Fatalf("Pluck 结果错误: %v", ages)
+	}
+
+	var names []string
+	if err := s.Pluck("Name", &names, "Age = ?", 25); err != nil || len(names) != 1 || names[0] != "张三" {
+		t.Fatalf("Pluck 结果错误: %v, %v", names, err)
+	}
+	if err := s.Pluck("Missing", &names); err == nil {
+		t.Fatal("期望未知列报错")
+	}
+}
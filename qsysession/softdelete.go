@@ -0,0 +1,62 @@
+package qsysession
+
+import (
+	"errors"
+	"fmt"
+	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
+)
+
+// Unscoped makes the next operation ignore soft deletion: queries include
+// deleted rows and Delete removes rows for good
+func (s *Session) Unscoped() *Session {
+	s.statement.unscoped = true
+	return s
+}
+
+// Restore un-deletes the soft-deleted records matching conds, combined with
+// any chained conditions. Like Delete, it refuses to run without conditions
+// unless AllowGlobalUpdate is chained.
+func (s *Session) Restore(conds ...interface{}) (int64, error) {
+	defer s.resetStatement()
+	if s.Schema == nil {
+		return 0, errors.New("schema is nil")
+	}
+	field := s.Schema.SoftDeleteField
+	if field == nil {
+		return 0, fmt.Errorf("model %s has no soft delete field", s.Schema.Name)
+	}
+	if err := s.applyConds(conds); err != nil {
+		return 0, err
+	}
+	if len(s.statement.where) == 0 && !s.statement.allowGlobal {
+		return 0, ErrMissingWhereClause
+	}
+
+	alive := aliveValue(field)
+	where := append(s.statement.where[:len(s.statement.where):len(s.statement.where)], qsyclause.Neq{Column: field.DBName, Value: alive})
+	affected, err := s.execUpdate(qsyclause.Assignments{{Column: field.DBName, Value: alive}}, where)
+	if err != nil {
+		return 0, err
+	}
+	s.forgetSnapshots()
+	return affected, nil
+}
+
+// aliveValue is the soft delete field value of a row that is not deleted:
+// NULL for nullable times, 0 for unix seconds
+func aliveValue(field *qsyschema.Field) interface{} {
+	if qsyschema.IsInteger(field.GoType) {
+		return 0
+	}
+	return nil
+}
+
+// deletedValue is the soft delete field value written by Delete
+func (s *Session) deletedValue(field *qsyschema.Field) interface{} {
+	now := s.now()
+	if qsyschema.IsInteger(field.GoType) {
+		return now.Unix()
+	}
+	return now
+}
@@ -0,0 +1,134 @@
+package qsysession_test
+
+import (
+	"database/sql"
+	"errors"
+	"qsyorm/qsysession"
+	"testing"
+	"time"
+)
+
+// Article 带 DeletedAt 字段，删除时只做标记
+type Article struct {
+	ID        int `qsy:"primarykey;autoincrement"`
+	Title     string
+	DeletedAt *time.Time
+}
+
+// Comment 用 softdelete 标签指定软删除字段
+type Comment struct {
+	ID      int `qsy:"primarykey;autoincrement"`
+	Body    string
+	Removed sql.NullTime `qsy:"softdelete"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	s := newTestSession(t, &Article{})
+	articles := []Article{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+	if _, err := s.Insert(articles); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	affected, err := s.Delete(&articles[0])
+	if err != nil || affected != 1 {
+		t.Fatalf("软删除错误: %d, %v", affected, err)
+	}
+	// 已删除的记录不会被再次标记
+	if affected, err := s.Delete("Title = ?", "a"); err != nil || affected != 0 {
+		t.Fatalf("重复软删除错误: %d, %v", affected, err)
+	}
+
+	if n, err := s.Count(); err != nil || n != 2 {
+		t.Fatalf("软删除后计数错误: %d, %v", n, err)
+	}
+	var article Article
+	if err := s.First(&article, "Title = ?", "a"); !errors.Is(err, qsysession.ErrRecordNotFound) {
+		t.Fatalf("期望查不到已删除记录，实际为 %v", err)
+	}
+	if affected, err := s.Where("Title = ?", "a").Update(map[string]interface{}{"Title": "x"}); err != nil || affected != 0 {
+		t.Fatalf("已删除记录不应被更新: %d, %v", affected, err)
+	}
+
+	var all []Article
+	if err := s.Unscoped().Order("ID").Find(&all); err != nil || len(all) != 3 || all[0].DeletedAt == nil || all[1].DeletedAt != nil {
+		t.Fatalf("Unscoped 查询错误: %v, %v", all, err)
+	}
+
+	if affected, err := s.Restore("Title = ?", "a"); err != nil || affected != 1 {
+		t.Fatalf("恢复错误: %d, %v", affected, err)
+	}
+	if n, _ := s.Count(); n != 3 {
+		t.Fatalf("恢复后计数错误: %d", n)
+	}
+
+	// Unscoped 删除是真正的删除
+	if affected, err := s.Unscoped().Delete("Title = ?", "b"); err != nil || affected != 1 {
+		t.Fatalf("硬删除错误: %d, %v", affected, err)
+	}
+	if n, _ := s.Unscoped().Count(); n != 2 {
+		t.Fatalf("硬删除后计数错误: %d", n)
+	}
+}
+
+func TestSoftDeleteTagged(t *testing.T) {
+	s := newTestSession(t, &Comment{})
+	if _, err := s.Insert(&Comment{Body: "a"}, &Comment{Body: "b"}); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if _, err := s.DeleteByID(1); err != nil {
+		t.Fatal("软删除失败:", err)
+	}
+
+	var comments []Comment
+	if err := s.Find(&comments); err != nil || len(comments) != 1 || comments[0].Body != "b" {
+		t.Fatalf("软删除后查询错误: %v, %v", comments, err)
+	}
+	comments = nil
+	if err := s.Unscoped().Find(&comments, "ID = ?", 1); err != nil || len(comments) != 1 || !comments[0].Removed.Valid {
+		t.Fatalf("软删除标记错误: %v, %v", comments, err)
+	}
+
+	if _, err := s.Restore(); !errors.Is(err, qsysession.ErrMissingWhereClause) {
+		t.Fatalf("期望 ErrMissingWhereClause，实际为 %v", err)
+	}
+	if affected, err := s.AllowGlobalUpdate().Restore(); err != nil || affected != 1 {
+		t.Fatalf("恢复错误: %d, %v", affected, err)
+	}
+}
+
+func TestSaveSoftDeleted(t *testing.T) {
+	s := newTestSession(t, &Article{})
+	article := &Article{Title: "a"}
+	if _, err := s.Insert(article); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if _, err := s.Delete(article); err != nil {
+		t.Fatal("软删除失败:", err)
+	}
+
+	// 保存已软删除的记录只更新原行，不会插入新的一行
+	var deleted Article
+	if err := s.Unscoped().First(&deleted); err != nil {
+		t.Fatal("查询已删除记录失败:", err)
+	}
+	deleted.Title = "b"
+	if n, err := s.Save(&deleted); err != nil || n != 1 {
+		t.Fatalf("保存已删除记录错误: %d, %v", n, err)
+	}
+	var all []Article
+	if err := s.Unscoped().Find(&all); err != nil || len(all) != 1 || all[0].Title != "b" || all[0].DeletedAt == nil {
+		t.Fatalf("保存已删除记录结果错误: %+v, %v", all, err)
+	}
+	if n, _ := s.Count(); n != 0 {
+		t.Fatalf("记录应保持删除状态，实际可见%d条", n)
+	}
+
+	// 删除标记为空的记录按原样写入，同一行恢复可见
+	article.Title = "c"
+	if n, err := s.Save(article); err != nil || n != 1 {
+		t.Fatalf("保存记录错误: %d, %v", n, err)
+	}
+	if n, _ := s.Unscoped().Count(); n != 1 {
+		t.Fatalf("Save 不应插入新行，实际共%d条", n)
+	}
+}
@@ -0,0 +1,300 @@
+package qsysession
+
+import (
+	"fmt"
+	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
+	"sort"
+)
+
+// statement 保存链式调用累积的查询状态，
+// 终结方法 (Find/Count/Update/Delete) 执行后会被重置
+type statement struct {
+	where       []qsyclause.Expression // joined with AND
+	selects     []string
+	omits       []string
+	orders      []string
+	limit       int
+	offset      int
+	hasLimit    bool
+	hasOffset   bool
+	batchTx     bool
+	onConflict  *qsyclause.OnConflict
+	sets        qsyclause.Assignments
+	allowGlobal bool
+	unscoped    bool
+	untracked   bool // Find 不保存快照，见 FindInBatches
+	table       string
+	err         error
+}
+
+// Where adds a condition joined to the previous ones with AND.
+// query is a SQL fragment with "?" placeholders, a qsyclause.Expression,
+// or a map[string]interface{} of column = value pairs.
+func (s *Session) Where(query interface{}, args ...interface{}) *Session {
+	if expr := s.buildCondition(query, args); expr != nil {
+		s.statement.where = append(s.statement.where, expr)
+	}
+	return s
+}
+
+// Or joins a condition to everything collected so far with OR
+func (s *Session) Or(query interface{}, args ...interface{}) *Session {
+	expr := s.buildCondition(query, args)
+	if expr == nil {
+		return s
+	}
+	if len(s.statement.where) == 0 {
+		s.statement.where = []qsyclause.Expression{expr}
+		return s
+	}
+	s.statement.where = []qsyclause.Expression{
+		qsyclause.Or(qsyclause.And(s.statement.where...), expr),
+	}
+	return s
+}
+
+// Not adds a negated condition joined to the previous ones with AND
+func (s *Session) Not(query interface{}, args ...interface{}) *Session {
+	if expr := s.buildCondition(query, args); expr != nil {
+		s.statement.where = append(s.statement.where, qsyclause.Not(expr))
+	}
+	return s
+}
+
+// Select restricts the columns read by Find or written by Update.
+// Columns may be given by column name or Go field name.
+func (s *Session) Select(columns ...string) *Session {
+	s.statement.selects = append(s.statement.selects, columns...)
+	return s
+}
+
+// Set assigns a column in the next Update; value may be an expression such
+// as qsyclause.Increment("Views", 1) or qsyclause.Expr("CURRENT_TIMESTAMP").
+// Columns, including those inside the expression, may be Go field names.
+func (s *Session) Set(column string, value interface{}) *Session {
+	s.statement.sets = append(s.statement.sets, qsyclause.Assignment{Column: column, Value: value})
+	return s
+}
+
+// Table runs the next operation against table instead of the model's own
+// table, e.g. an archive table sharing the model's columns
+func (s *Session) Table(name string) *Session {
+	s.statement.table = name
+	return s
+}
+
+// tableName returns the table of the next operation, see Table
+func (s *Session) tableName() string {
+	if s.statement.table != "" {
+		return s.statement.table
+	}
+	return s.Schema.GetTableName()
+}
+
+// quotedTable returns tableName quoted for the dialect
+func (s *Session) quotedTable() string {
+	return s.dialect.Quote(s.tableName())
+}
+
+// quoteNames quotes column names for the dialect, so that reserved words
+// can be used as columns
+func (s *Session) quoteNames(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = s.dialect.Quote(name)
+	}
+	return quoted
+}
+
+// AllowGlobalUpdate lets the next Update, Delete or Restore run without
+// conditions, affecting every row
+func (s *Session) AllowGlobalUpdate() *Session {
+	s.statement.allowGlobal = true
+	return s
+}
+
+// Omit excludes columns from Find and Update
+func (s *Session) Omit(columns ...string) *Session {
+	s.statement.omits = append(s.statement.omits, columns...)
+	return s
+}
+
+// Order appends an ORDER BY item such as "Age DESC"
+func (s *Session) Order(value string) *Session {
+	if value != "" {
+		s.statement.orders = append(s.statement.orders, value)
+	}
+	return s
+}
+
+// Limit sets the maximum number of rows returned by Find
+func (s *Session) Limit(limit int) *Session {
+	s.statement.limit = limit
+	s.statement.hasLimit = true
+	return s
+}
+
+// Offset sets the number of rows skipped by Find
+func (s *Session) Offset(offset int) *Session {
+	s.statement.offset = offset
+	s.statement.hasOffset = true
+	return s
+}
+
+// buildCondition converts the arguments of Where/Or/Not into an expression
+func (s *Session) buildCondition(query interface{}, args []interface{}) qsyclause.Expression {
+	switch q := query.(type) {
+	case string:
+		if q == "" {
+			return nil
+		}
+		return qsyclause.Raw{SQL: q, Vars: args}
+	case qsyclause.Expression:
+		return q
+	case map[string]interface{}:
+		keys := make([]string, 0, len(q))
+		for k := range q {
+			keys = append(keys, k)
+		}
+		// 排序保证生成的 SQL 稳定
+		sort.Strings(keys)
+		exprs := make([]qsyclause.Expression, 0, len(keys))
+		for _, k := range keys {
+			column := k
+			if s.Schema != nil {
+				if field := s.lookupField(k); field != nil {
+					column = field.DBName
+				}
+			}
+			exprs = append(exprs, qsyclause.Eq{Column: column, Value: q[k]})
+		}
+		return qsyclause.And(exprs...)
+	}
+	if s.statement.err == nil {
+		s.statement.err = fmt.Errorf("unsupported condition type %T", query)
+	}
+	return nil
+}
+
+// resetStatement clears the chained state once a terminal method has run
+func (s *Session) resetStatement() {
+	s.statement = statement{}
+}
+
+// applyConds turns the inline conditions of a terminal method into a Where
+// call and reports any error recorded while chaining
+func (s *Session) applyConds(conds []interface{}) error {
+	if len(conds) > 0 {
+		s.Where(conds[0], conds[1:]...)
+	}
+	return s.statement.err
+}
+
+// selectFields returns the schema fields read by Find, honouring Select and Omit
+func (s *Session) selectFields() ([]*qsyschema.Field, error) {
+	fields := s.Schema.Fields
+	if len(s.statement.selects) > 0 {
+		selected, err := s.lookupFields(s.statement.selects)
+		if err != nil {
+			return nil, err
+		}
+		fields = selected
+	}
+	if len(s.statement.omits) == 0 {
+		return fields, nil
+	}
+	omitted, err := s.lookupFields(s.statement.omits)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]*qsyschema.Field, 0, len(fields))
+	for _, field := range fields {
+		if !containsField(omitted, field) {
+			kept = append(kept, field)
+		}
+	}
+	return kept, nil
+}
+
+// lookupField finds a schema field by column name or Go field name
+func (s *Session) lookupField(name string) *qsyschema.Field {
+	if field := s.Schema.GetField(name); field != nil {
+		return field
+	}
+	if goName, ok := s.Schema.DbFieldToGo[name]; ok {
+		return s.Schema.GetField(goName)
+	}
+	return nil
+}
+
+// lookupFields resolves names with lookupField, failing on unknown columns
+func (s *Session) lookupFields(names []string) ([]*qsyschema.Field, error) {
+	fields := make([]*qsyschema.Field, 0, len(names))
+	for _, name := range names {
+		field := s.lookupField(name)
+		if field == nil {
+			return nil, fmt.Errorf("unknown column %s in model %s", name, s.Schema.Name)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func containsField(fields []*qsyschema.Field, target *qsyschema.Field) bool {
+	for _, field := range fields {
+		if field == target {
+			return true
+		}
+	}
+	return false
+}
+
+// whereExprs returns the collected conditions plus, for soft-deleted
+// models, the filter that hides deleted rows unless Unscoped is chained
+func (s *Session) whereExprs() []qsyclause.Expression {
+	where := s.statement.where
+	if field := s.Schema.SoftDeleteField; field != nil && !s.statement.unscoped {
+		where = append(where[:len(where):len(where)], qsyclause.Eq{Column: field.DBName, Value: aliveValue(field)})
+	}
+	return where
+}
+
+// buildWhere renders the collected conditions into the WHERE clause of builder
+func (s *Session) buildWhere(builder *qsyclause.Builder) {
+	where := s.whereExprs()
+	if len(where) == 0 {
+		return
+	}
+	builder.Set(qsyclause.WHERE, qsyclause.Where{Exprs: where})
+}
+
+// buildPagination renders ORDER BY, LIMIT and OFFSET into builder
+func (s *Session) buildPagination(builder *qsyclause.Builder) {
+	if len(s.statement.orders) > 0 {
+		orderSql, orderVars := qsyclause.BuildOrders(s.statement.orders)
+		setClause(builder, qsyclause.ORDERBY, orderSql, orderVars)
+	}
+	if s.statement.hasLimit {
+		limitSql, limitVars := qsyclause.BuildLimit(s.statement.limit)
+		setClause(builder, qsyclause.LIMIT, limitSql, limitVars)
+	} else if s.statement.hasOffset {
+		// SQLite 不允许没有 LIMIT 的 OFFSET，-1 表示不限制
+		limitSql, limitVars := qsyclause.BuildLimit(-1)
+		setClause(builder, qsyclause.LIMIT, limitSql, limitVars)
+	}
+	if s.statement.hasOffset {
+		offsetSql, offsetVars := qsyclause.BuildOffset(s.statement.offset)
+		setClause(builder, qsyclause.OFFSET, offsetSql, offsetVars)
+	}
+}
+
+// setClause stores sql and its vars under typ in builder
+func setClause(builder *qsyclause.Builder, typ qsyclause.Type, sql string, vars []interface{}) {
+	builder.Set(typ, append([]interface{}{sql}, vars...)...)
+}
+
+// newBuilder creates a clause builder that renders with the session dialect
+func (s *Session) newBuilder() *qsyclause.Builder {
+	return qsyclause.New().WithDialect(s.dialect)
+}
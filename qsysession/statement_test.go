@@ -0,0 +1,148 @@
+package qsysession_test
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"qsyorm/qsyclause"
+	"qsyorm/qsydialect"
+	"qsyorm/qsylog"
+	"qsyorm/qsysession"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestSession 打开一个临时数据库并为 model 建表
+func newTestSession(t *testing.T, model interface{}) *qsysession.Session {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("打开数据库失败:", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := qsylog.New(log.New(os.Stdout, "", log.LstdFlags), qsylog.Config{
+		Loglevel: qsylog.Error,
+	})
+	dialect, _ := qsydialect.GetDialect("sqlite3")
+	session := qsysession.NewSession(db, logger, dialect).Model(model)
+	if err := session.CreateTable(); err != nil {
+		t.Fatal("创建表失败:", err)
+	}
+	return session
+}
+
+func seedTestUsers(t *testing.T, s *qsysession.Session) {
+	t.Helper()
+	for _, u := range []*TestUser{
+		{Name: "张三", Age: 25},
+		{Name: "李四", Age: 30},
+		{Name: "王五", Age: 35},
+		{Name: "赵六", Age: 40},
+	} {
+		if _, err := s.Insert(u); err != nil {
+			t.Fatal("插入记录失败:", err)
+		}
+	}
+}
+
+func TestChainFind(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	var users []TestUser
+	err := s.Where("Age > ?", 25).Order("Age DESC").Limit(2).Offset(1).Find(&users)
+	if err != nil {
+		t.Fatal("链式查询失败:", err)
+	}
+	if len(users) != 2 || users[0].Name != "王五" || users[1].Name != "李四" {
+		t.Fatalf("链式查询结果错误: %v", users)
+	}
+
+	// 状态在终结方法后被重置
+	users = nil
+	if err := s.Find(&users); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	if len(users) != 4 {
+		t.Fatalf("期望4条记录，实际%d条", len(users))
+	}
+
+	users = nil
+	if err := s.Where("Age < ?", 30).Or("Name = ?", "赵六").Order("Age").Find(&users); err != nil {
+		t.Fatal("Or 查询失败:", err)
+	}
+	if len(users) != 2 || users[0].Name != "张三" || users[1].Name != "赵六" {
+		t.Fatalf("Or 查询结果错误: %v", users)
+	}
+
+	users = nil
+	if err := s.Select("Name").Not("Age >= ?", 30).Find(&users); err != nil {
+		t.Fatal("Select 查询失败:", err)
+	}
+	if len(users) != 1 || users[0].Name != "张三" || users[0].Age != 0 {
+		t.Fatalf("Select 查询结果错误: %v", users)
+	}
+
+	if err := s.Select("Missing").Find(&users); err == nil {
+		t.Fatal("期望未知列报错")
+	}
+}
+
+func TestChainWrite(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	count, err := s.Where("Age >= ?", 30).Count()
+	if err != nil || count != 3 {
+		t.Fatalf("计数错误: %d, %v", count, err)
+	}
+
+	affected, err := s.Where("Name = ?", "张三").Update(&TestUser{Name: "张三", Age: 26})
+	if err != nil || affected != 1 {
+		t.Fatalf("更新错误: %d, %v", affected, err)
+	}
+
+	affected, err = s.Where("Age > ?", 26).Not("Name = ?", "赵六").Delete()
+	if err != nil || affected != 2 {
+		t.Fatalf("删除错误: %d, %v", affected, err)
+	}
+
+	count, err = s.Count()
+	if err != nil || count != 2 {
+		t.Fatalf("删除后计数错误: %d, %v", count, err)
+	}
+}
+
+func TestChainExpression(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+
+	var users []TestUser
+	err := s.Where(qsyclause.Or(
+		qsyclause.Eq{Column: "Name", Value: "张三"},
+		qsyclause.Between{Column: "Age", Low: 35, High: 40},
+	)).Where("Age <> ?", 40).Order("Age").Find(&users)
+	if err != nil {
+		t.Fatal("表达式查询失败:", err)
+	}
+	if len(users) != 2 || users[0].Name != "张三" || users[1].Name != "王五" {
+		t.Fatalf("表达式查询结果错误: %v", users)
+	}
+
+	count, err := s.Count(map[string]interface{}{"Name": "李四", "Age": 30})
+	if err != nil || count != 1 {
+		t.Fatalf("map 条件计数错误: %d, %v", count, err)
+	}
+
+	count, err = s.Where("ID IN ?", []int{1, 2, 3}).Count()
+	if err != nil || count != 3 {
+		t.Fatalf("IN 条件计数错误: %d, %v", count, err)
+	}
+
+	if _, err := s.Count(42); err == nil {
+		t.Fatal("期望不支持的条件类型报错")
+	}
+}
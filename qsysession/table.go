@@ -26,7 +26,7 @@ func (s *Session) Model(model interface{}) *Session {
 	// 如果Schema为nil或者模型类型不同，则重新解析
 	if s.Schema == nil || schemaType != modelType {
 		s.Logger.Info("Creating new Schema for type: %s", modelType.Name())
-		s.Schema = qsyschema.Parse(model, s.dialect)
+		s.Schema = qsyschema.ParseWithNamer(model, s.dialect, s.namer)
 	} else {
 		s.Logger.Info("Reusing existing Schema for type: %s", modelType.Name())
 	}
@@ -35,6 +35,7 @@ func (s *Session) Model(model interface{}) *Session {
 }
 
 func (s *Session) CreateTable() error {
+	defer s.resetStatement()
 	table := s.Ref()
 	var columns []string
 	var indexes []string
@@ -45,38 +46,24 @@ func (s *Session) CreateTable() error {
 	}
 
 	for _, field := range table.Fields {
-		// SQLite对字段名不区分大小写，但在创建时保留原始大小写
-		fieldName := field.Name
+		// 列的类型、约束、默认值和注释由方言渲染
+		columns = append(columns, s.dialect.ColumnDefinition(field.ColumnDefinition()))
+	}
 
-		// 处理 SQLite 的特殊情况
-		if field.Type == "INTEGER" && field.IsPrimaryKey && field.IsAutoIncrement {
-			// SQLite 要求 AUTOINCREMENT 必须按照 INTEGER PRIMARY KEY AUTOINCREMENT 顺序
-			columns = append(columns, fmt.Sprintf("%s INTEGER PRIMARY KEY AUTOINCREMENT", fieldName))
-		} else {
-			columnss := []string{fieldName, field.Type}
-			if field.IsPrimaryKey && !field.IsAutoIncrement {
-				columnss = append(columnss, "PRIMARY KEY")
-			}
-			if field.Unique {
-				columnss = append(columnss, "UNIQUE")
-			}
-			columns = append(columns, strings.Join(columnss, " "))
-		}
+	// 表名由 Table、TableName() 或命名策略决定，默认是结构体名称的小写形式
+	tableName := s.tableName()
 
-		// 对需要创建索引的字段，添加到索引列表
-		if field.Index {
-			indexSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s(%s);",
-				strings.ToLower(table.Name), strings.ToLower(field.Name),
-				strings.ToLower(table.Name), field.Name)
-			indexes = append(indexes, indexSQL)
+	// 索引来自 index 和 uniqueIndex 标签，同名的字段组成复合索引
+	for _, index := range table.Indexes {
+		name := index.Name
+		// 自动生成的索引名跟随 Table 指定的表名，避免与模型表的索引重名
+		if prefix := "idx_" + table.GetTableName() + "_"; tableName != table.GetTableName() && strings.HasPrefix(name, prefix) {
+			name = "idx_" + tableName + "_" + strings.TrimPrefix(name, prefix)
 		}
+		indexes = append(indexes, s.createIndexSQL(tableName, name, index))
 	}
 
-	// 确保表名使用的是小写
-	tableName := strings.ToLower(table.Name)
-
-	// 使用表名的小写形式
-	createtablesql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", tableName, strings.Join(columns, ", "))
+	createtablesql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", s.dialect.Quote(tableName), strings.Join(columns, ", "))
 	s.Logger.Info("SQL: %s", createtablesql)
 	if _, err := s.Raw(createtablesql).Exec(); err != nil {
 		s.Logger.Error("Failed to create table: %s", err.Error())
@@ -95,8 +82,32 @@ func (s *Session) CreateTable() error {
 	return nil
 }
 
+// createIndexSQL renders the CREATE INDEX statement of index on table;
+// names are quoted, expressions are written as given
+func (s *Session) createIndexSQL(table, name string, index *qsyschema.Index) string {
+	var sql strings.Builder
+	sql.WriteString("CREATE ")
+	if index.Unique {
+		sql.WriteString("UNIQUE ")
+	}
+	columns := make([]string, len(index.Fields))
+	for i, field := range index.Fields {
+		if field.Expression == "" {
+			field.Expression = s.dialect.Quote(field.Field.DBName)
+		}
+		columns[i] = field.Column()
+	}
+	fmt.Fprintf(&sql, "INDEX IF NOT EXISTS %s ON %s(%s)", s.dialect.Quote(name), s.dialect.Quote(table), strings.Join(columns, ", "))
+	if index.Where != "" {
+		sql.WriteString(" WHERE " + index.Where)
+	}
+	sql.WriteString(";")
+	return sql.String()
+}
+
 func (s *Session) DropTable() error {
-	droptable := fmt.Sprintf("DROP TABLE IF EXISTS %s", s.Schema.GetTableName())
+	defer s.resetStatement()
+	droptable := fmt.Sprintf("DROP TABLE IF EXISTS %s", s.quotedTable())
 	_, err := s.Raw(droptable).Exec()
 	return err
 }
@@ -0,0 +1,97 @@
+package qsysession
+
+import (
+	"qsyorm/qsyclause"
+	"qsyorm/qsyschema"
+	"reflect"
+	"time"
+)
+
+// timestampValue converts now to the storage of an automatic timestamp
+func timestampValue(kind qsyschema.AutoTime, now time.Time) interface{} {
+	switch kind {
+	case qsyschema.AutoTimeUnix:
+		return now.Unix()
+	case qsyschema.AutoTimeUnixMilli:
+		return now.UnixMilli()
+	}
+	return now
+}
+
+// stampTime writes now into the timestamp field v when it can be set, and
+// returns the value to bind for it
+func stampTime(v reflect.Value, kind qsyschema.AutoTime, now time.Time) interface{} {
+	value := timestampValue(kind, now)
+	if !v.CanSet() {
+		return value
+	}
+	switch {
+	case kind != qsyschema.AutoTimeValue:
+		setInteger(v, value.(int64))
+	case v.Kind() == reflect.Ptr:
+		v.Set(reflect.ValueOf(&now))
+	default:
+		v.Set(reflect.ValueOf(now))
+	}
+	return value
+}
+
+// insertTimestamp returns the value bound for field on insert: the current
+// time for a zero CreatedAt/UpdatedAt, otherwise the value of v
+func (s *Session) insertTimestamp(v reflect.Value, field *qsyschema.Field, now time.Time) interface{} {
+	kind := field.AutoCreateTime
+	if kind == qsyschema.AutoTimeNone {
+		kind = field.AutoUpdateTime
+	}
+	if kind == qsyschema.AutoTimeNone || !v.IsZero() {
+		return v.Interface()
+	}
+	return stampTime(v, kind, now)
+}
+
+// updateTimeFields returns the UpdatedAt fields of the model. They are
+// written whether or not Select names them; only Omit leaves them out.
+func (s *Session) updateTimeFields() []*qsyschema.Field {
+	var fields []*qsyschema.Field
+	for _, field := range s.Schema.Fields {
+		if field.AutoUpdateTime == qsyschema.AutoTimeNone {
+			continue
+		}
+		omitted := false
+		for _, name := range s.statement.omits {
+			if s.lookupField(name) == field {
+				omitted = true
+			}
+		}
+		if !omitted {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// touchUpdateTime sets the UpdatedAt columns to now. Struct updates
+// overwrite the column and the record; map and Set updates only add it
+// when the caller did not assign it.
+func (s *Session) touchUpdateTime(assignments qsyclause.Assignments, record reflect.Value) qsyclause.Assignments {
+	now := s.now()
+	for _, field := range s.updateTimeFields() {
+		value := timestampValue(field.AutoUpdateTime, now)
+		if record.IsValid() {
+			value = stampTime(s.fieldValue(record, field), field.AutoUpdateTime, now)
+		}
+		assigned := false
+		for i := range assignments {
+			if assignments[i].Column == field.DBName {
+				assigned = true
+				if record.IsValid() {
+					assignments[i].Value = value
+				}
+			}
+		}
+		if !assigned {
+			assignments = append(assignments, qsyclause.Assignment{Column: field.DBName, Value: value})
+		}
+	}
+	return assignments
+}
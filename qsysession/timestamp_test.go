@@ -0,0 +1,91 @@
+package qsysession_test
+
+import (
+	"testing"
+	"time"
+)
+
+// Note 的 CreatedAt 按名称识别，其余时间戳字段通过标签指定
+type Note struct {
+	ID        int `qsy:"primarykey;autoincrement"`
+	Text      string
+	CreatedAt time.Time
+	Edited    int64 `qsy:"autoUpdateTime:milli"`
+	Published int64 `qsy:"autoCreateTime"`
+}
+
+func TestAutoTimestamps(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSession(t, &Note{}).WithClock(func() time.Time { return now })
+
+	note := &Note{Text: "a"}
+	if _, err := s.Insert(note); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if !note.CreatedAt.Equal(now) || note.Published != now.Unix() || note.Edited != now.UnixMilli() {
+		t.Fatalf("插入时未写回时间戳: %+v", note)
+	}
+
+	// 已经设置的创建时间保持不变
+	earlier := now.Add(-time.Hour)
+	if _, err := s.Insert(&Note{Text: "b", CreatedAt: earlier}); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	var stored Note
+	if err := s.Get(&stored, 2); err != nil || !stored.CreatedAt.Equal(earlier) {
+		t.Fatalf("显式创建时间被覆盖: %+v, %v", stored, err)
+	}
+
+	now = now.Add(time.Minute)
+	note.Text = "a2"
+	if _, err := s.Where("ID = ?", note.ID).Update(note); err != nil {
+		t.Fatal("更新失败:", err)
+	}
+	if note.Edited != now.UnixMilli() {
+		t.Fatalf("结构体更新未写回更新时间: %+v", note)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := s.Where("ID = ?", 2).Update(map[string]interface{}{"Text": "b2"}); err != nil {
+		t.Fatal("map 更新失败:", err)
+	}
+	stored = Note{}
+	if err := s.Get(&stored, 2); err != nil || stored.Edited != now.UnixMilli() || !stored.CreatedAt.Equal(earlier) {
+		t.Fatalf("map 更新时间戳错误: %+v, %v", stored, err)
+	}
+
+	// Select 没有包含时间戳列时仍然更新时间戳
+	now = now.Add(time.Minute)
+	note.Text = "a3"
+	if _, err := s.Select("Text").Update(note); err != nil {
+		t.Fatal("Select 更新失败:", err)
+	}
+	stored = Note{}
+	if err := s.Get(&stored, note.ID); err != nil || stored.Text != "a3" || stored.Edited != now.UnixMilli() || note.Edited != now.UnixMilli() {
+		t.Fatalf("Select 更新未修改时间戳: %+v, %+v, %v", stored, note, err)
+	}
+
+	// 只有 Omit 可以排除时间戳列
+	var before Note
+	if err := s.Get(&before, 2); err != nil {
+		t.Fatal("查询失败:", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := s.Omit("Edited").Where("ID = ?", 2).Update(map[string]interface{}{"Text": "b3"}); err != nil {
+		t.Fatal("Omit 更新失败:", err)
+	}
+	stored = Note{}
+	if err := s.Get(&stored, 2); err != nil || stored.Text != "b3" || stored.Edited != before.Edited {
+		t.Fatalf("Omit 更新不应修改时间戳: %+v, %v", stored, err)
+	}
+
+	// UpdateBatch 的列清单同样不排除时间戳列
+	now = now.Add(time.Minute)
+	if _, err := s.UpdateBatch([]*Note{{ID: 2, Text: "b4"}}, "Text"); err != nil {
+		t.Fatal("批量更新失败:", err)
+	}
+	stored = Note{}
+	if err := s.Get(&stored, 2); err != nil || stored.Text != "b4" || stored.Edited != now.UnixMilli() {
+		t.Fatalf("批量更新未修改时间戳: %+v, %v", stored, err)
+	}
+}
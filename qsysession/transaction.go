@@ -1,15 +1,15 @@
 package qsysession
 
-// Begin starts a transaction
+// Begin starts a transaction bound to the session context
 func (s *Session) Begin() (err error) {
 	if s.tx != nil {
 		return
 	}
 
-	s.Logger.Info("transaction begin")
-	s.tx, err = s.db.Begin()
+	s.logger().Info("transaction begin")
+	s.tx, err = s.db.BeginTx(s.Context(), nil)
 	if err != nil {
-		s.Logger.Error("failed to begin transaction: %v", err)
+		s.logger().Error("failed to begin transaction: %v", err)
 		return
 	}
 
@@ -22,14 +22,17 @@ func (s *Session) Commit() (err error) {
 		return
 	}
 
-	s.Logger.Info("transaction commit")
+	s.logger().Info("transaction commit")
 	err = s.tx.Commit()
+	s.tx = nil
 	if err != nil {
-		s.Logger.Error("failed to commit transaction: %v", err)
+		// 提交失败的事务已经结束，按回滚处理
+		s.logger().Error("failed to commit transaction: %v", err)
+		s.undoTransaction()
 		return
 	}
 
-	s.tx = nil
+	s.rollbacks = nil
 	return
 }
 
@@ -39,17 +42,33 @@ func (s *Session) Rollback() (err error) {
 		return
 	}
 
-	s.Logger.Info("transaction rollback")
+	s.logger().Info("transaction rollback")
 	err = s.tx.Rollback()
+	s.tx = nil
+	s.undoTransaction()
 	if err != nil {
-		s.Logger.Error("failed to rollback transaction: %v", err)
-		return
+		s.logger().Error("failed to rollback transaction: %v", err)
 	}
-
-	s.tx = nil
 	return
 }
 
+// onRollback registers undo to revert an in-memory change, such as an id
+// written back into a record, if the current transaction is rolled back.
+// Outside a transaction it does nothing.
+func (s *Session) onRollback(undo func()) {
+	if s.tx != nil {
+		s.rollbacks = append(s.rollbacks, undo)
+	}
+}
+
+// undoTransaction runs the registered undo functions, latest first
+func (s *Session) undoTransaction() {
+	for i := len(s.rollbacks) - 1; i >= 0; i-- {
+		s.rollbacks[i]()
+	}
+	s.rollbacks = nil
+}
+
 // Transaction executes a function within a transaction
 // If the function returns an error, the transaction is rolled back
 // Otherwise, the transaction is committed
@@ -0,0 +1,190 @@
+package qsysession
+
+import (
+	"context"
+	"qsyorm/qsydialect"
+	"qsyorm/qsyschema"
+	"reflect"
+	"sync"
+)
+
+// typedSchemas caches the schema of each model type per dialect,
+// so a TypedQuery parses its model only once
+var typedSchemas sync.Map
+
+type typedSchemaKey struct {
+	typ     reflect.Type
+	dialect qsydialect.Dialect
+	namer   qsyschema.Namer
+}
+
+// TypedQuery wraps a Session for model T so that results are returned as
+// T values and model mismatches are caught at compile time
+type TypedQuery[T any] struct {
+	session *Session
+	schema  *qsyschema.Schema
+}
+
+// Typed binds s to model T
+func Typed[T any](s *Session) *TypedQuery[T] {
+	q := &TypedQuery[T]{session: s, schema: typedSchema[T](s)}
+	s.Schema = q.schema
+	return q
+}
+
+// typedSchema returns the cached schema of T for the session dialect.
+// A namer that cannot be a map key, e.g. a struct holding a map, is
+// parsed every time instead of cached.
+func typedSchema[T any](s *Session) *qsyschema.Schema {
+	if s.namer != nil && !reflect.TypeOf(s.namer).Comparable() {
+		return qsyschema.ParseWithNamer(new(T), s.dialect, s.namer)
+	}
+	key := typedSchemaKey{typ: reflect.TypeOf((*T)(nil)).Elem(), dialect: s.dialect, namer: s.namer}
+	schema, ok := typedSchemas.Load(key)
+	if !ok {
+		schema, _ = typedSchemas.LoadOrStore(key, qsyschema.ParseWithNamer(new(T), s.dialect, s.namer))
+	}
+	return schema.(*qsyschema.Schema)
+}
+
+// Session returns the underlying session
+func (q *TypedQuery[T]) Session() *Session {
+	return q.session
+}
+
+// Where adds a condition joined with AND, see Session.Where
+func (q *TypedQuery[T]) Where(query interface{}, args ...interface{}) *TypedQuery[T] {
+	q.session.Where(query, args...)
+	return q
+}
+
+// Or adds a condition joined with OR, see Session.Or
+func (q *TypedQuery[T]) Or(query interface{}, args ...interface{}) *TypedQuery[T] {
+	q.session.Or(query, args...)
+	return q
+}
+
+// Not adds a negated condition, see Session.Not
+func (q *TypedQuery[T]) Not(query interface{}, args ...interface{}) *TypedQuery[T] {
+	q.session.Not(query, args...)
+	return q
+}
+
+// Table runs the next operation against another table, see Session.Table
+func (q *TypedQuery[T]) Table(name string) *TypedQuery[T] {
+	q.session.Table(name)
+	return q
+}
+
+// Select restricts the columns read by Find
+func (q *TypedQuery[T]) Select(columns ...string) *TypedQuery[T] {
+	q.session.Select(columns...)
+	return q
+}
+
+// Order appends an ORDER BY item such as "Age DESC"
+func (q *TypedQuery[T]) Order(value string) *TypedQuery[T] {
+	q.session.Order(value)
+	return q
+}
+
+// Limit sets the maximum number of rows returned
+func (q *TypedQuery[T]) Limit(limit int) *TypedQuery[T] {
+	q.session.Limit(limit)
+	return q
+}
+
+// Offset sets the number of rows skipped
+func (q *TypedQuery[T]) Offset(offset int) *TypedQuery[T] {
+	q.session.Offset(offset)
+	return q
+}
+
+// prepare binds ctx and the schema of T to the session before a terminal call
+func (q *TypedQuery[T]) prepare(ctx context.Context) *Session {
+	q.session.Schema = q.schema
+	return q.session.WithContext(ctx)
+}
+
+// Find returns all records matching the collected conditions
+func (q *TypedQuery[T]) Find(ctx context.Context) ([]T, error) {
+	var results []T
+	err := q.prepare(ctx).Find(&results)
+	return results, err
+}
+
+// First returns the first record ordered by primary key
+func (q *TypedQuery[T]) First(ctx context.Context) (T, error) {
+	var result T
+	err := q.prepare(ctx).First(&result)
+	return result, err
+}
+
+// Last returns the last record ordered by primary key
+func (q *TypedQuery[T]) Last(ctx context.Context) (T, error) {
+	var result T
+	err := q.prepare(ctx).Last(&result)
+	return result, err
+}
+
+// Take returns one record without ordering
+func (q *TypedQuery[T]) Take(ctx context.Context) (T, error) {
+	var result T
+	err := q.prepare(ctx).Take(&result)
+	return result, err
+}
+
+// Get returns the record with the given primary key
+func (q *TypedQuery[T]) Get(ctx context.Context, pk ...interface{}) (T, error) {
+	var result T
+	err := q.prepare(ctx).Get(&result, pk...)
+	return result, err
+}
+
+// Count returns the number of matching records
+func (q *TypedQuery[T]) Count(ctx context.Context) (int64, error) {
+	return q.prepare(ctx).Count()
+}
+
+// Insert inserts values and writes generated ids back into them
+func (q *TypedQuery[T]) Insert(ctx context.Context, values ...*T) (int64, error) {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return q.prepare(ctx).Insert(args...)
+}
+
+// Update writes value to the matching records
+func (q *TypedQuery[T]) Update(ctx context.Context, value *T) (int64, error) {
+	return q.prepare(ctx).Update(value)
+}
+
+// UpdateBatch writes values with one CASE statement per chunk, see Session.UpdateBatch
+func (q *TypedQuery[T]) UpdateBatch(ctx context.Context, values []T, cols ...string) ([]int64, error) {
+	return q.prepare(ctx).UpdateBatch(values, cols...)
+}
+
+// AllowGlobalUpdate lets the next Update or Delete run without conditions
+func (q *TypedQuery[T]) AllowGlobalUpdate() *TypedQuery[T] {
+	q.session.AllowGlobalUpdate()
+	return q
+}
+
+// Delete removes the matching records
+func (q *TypedQuery[T]) Delete(ctx context.Context) (int64, error) {
+	return q.prepare(ctx).Delete()
+}
+
+// DeleteRecords removes records by primary key, running hooks on each of them
+func (q *TypedQuery[T]) DeleteRecords(ctx context.Context, records ...*T) (int64, error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+	return q.prepare(ctx).Delete(records)
+}
+
+// DeleteByID removes the records with the given primary keys
+func (q *TypedQuery[T]) DeleteByID(ctx context.Context, ids ...interface{}) (int64, error) {
+	return q.prepare(ctx).DeleteByID(ids...)
+}
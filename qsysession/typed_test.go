@@ -0,0 +1,102 @@
+package qsysession_test
+
+import (
+	"context"
+	"errors"
+	"qsyorm/qsysession"
+	"testing"
+)
+
+func TestTypedQuery(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	ctx := context.Background()
+	users := qsysession.Typed[TestUser](s)
+
+	alice := &TestUser{Name: "Alice", Age: 20}
+	bob := &TestUser{Name: "Bob", Age: 30}
+	if _, err := users.Insert(ctx, alice, bob); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	if alice.ID != 1 || bob.ID != 2 {
+		t.Fatalf("插入后 ID 错误: %d, %d", alice.ID, bob.ID)
+	}
+
+	found, err := users.Where("Age > ?", 25).Find(ctx)
+	if err != nil || len(found) != 1 || found[0].Name != "Bob" {
+		t.Fatalf("Find 结果错误: %v, %v", found, err)
+	}
+
+	first, err := users.First(ctx)
+	if err != nil || first.Name != "Alice" {
+		t.Fatalf("First 结果错误: %v, %v", first, err)
+	}
+
+	got, err := users.Get(ctx, 2)
+	if err != nil || got.Name != "Bob" {
+		t.Fatalf("Get 结果错误: %v, %v", got, err)
+	}
+
+	if _, err := users.Where("Age > ?", 100).First(ctx); !errors.Is(err, qsysession.ErrRecordNotFound) {
+		t.Fatalf("期望 ErrRecordNotFound，实际 %v", err)
+	}
+
+	bob.Age = 31
+	if n, err := users.Where("ID = ?", bob.ID).Update(ctx, bob); err != nil || n != 1 {
+		t.Fatalf("Update 失败: %d, %v", n, err)
+	}
+	if n, err := users.Where("Age = ?", 31).Count(ctx); err != nil || n != 1 {
+		t.Fatalf("Count 结果错误: %d, %v", n, err)
+	}
+	if n, err := users.Where("Name = ?", "Alice").Delete(ctx); err != nil || n != 1 {
+		t.Fatalf("Delete 失败: %d, %v", n, err)
+	}
+
+	// 会话切换到其它模型后，typed 查询仍使用自己的 schema
+	s.Model(&BatchUser{})
+	if n, err := users.Count(ctx); err != nil || n != 1 {
+		t.Fatalf("切换模型后 Count 结果错误: %d, %v", n, err)
+	}
+}
+
+// CountingUser 的钩子修改接收者
+type CountingUser struct {
+	ID    int `qsy:"primarykey;autoincrement"`
+	Name  string
+	Calls int
+}
+
+func (u *CountingUser) BeforeDelete() error {
+	u.Calls++
+	return nil
+}
+
+func (u *CountingUser) BeforeUpdate() error {
+	u.Calls++
+	return nil
+}
+
+func TestTypedHooksOnNewModel(t *testing.T) {
+	s := newTestSession(t, &CountingUser{})
+	ctx := context.Background()
+	users := qsysession.Typed[CountingUser](s)
+	if _, err := users.Insert(ctx, &CountingUser{Name: "a"}, &CountingUser{Name: "b"}); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	// 缓存的 schema 在所有 typed 查询间共享，没有记录的钩子不能修改它的模型
+	if _, err := users.Session().Set("Name", "c").Where("Name = ?", "a").Update(nil); err != nil {
+		t.Fatal("Set 更新失败:", err)
+	}
+	if _, err := users.Session().Where("Name = ?", "b").Update(map[string]interface{}{"Name": "d"}); err != nil {
+		t.Fatal("map 更新失败:", err)
+	}
+	if n, err := users.Where("Name = ?", "c").Delete(ctx); err != nil || n != 1 {
+		t.Fatalf("Delete 失败: %d, %v", n, err)
+	}
+	if n, err := users.DeleteByID(ctx, 2); err != nil || n != 1 {
+		t.Fatalf("DeleteByID 失败: %d, %v", n, err)
+	}
+	if model := s.Schema.Model.(*CountingUser); model.Calls != 0 {
+		t.Fatalf("钩子修改了共享的模型: %+v", model)
+	}
+}
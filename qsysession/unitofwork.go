@@ -0,0 +1,323 @@
+package qsysession
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnitOfWork tracks the entities of a business operation and writes all
+// their changes in one transaction on Commit. Entities loaded through the
+// unit are kept in an identity map, so the same primary key always yields
+// the same pointer, and are updated on Commit when they changed (see
+// Session.Changes). A UnitOfWork is not safe for concurrent use.
+type UnitOfWork struct {
+	session  *Session
+	identity map[snapshotKey]interface{}
+	loaded   []interface{}
+	news     []interface{}
+	dirty    []interface{}
+	removed  []interface{}
+	parents  map[reflect.Type][]reflect.Type // 实体类型依赖的类型，见 DependsOn
+}
+
+// NewUnitOfWork starts a unit of work on s
+func NewUnitOfWork(s *Session) *UnitOfWork {
+	return &UnitOfWork{
+		session:  s,
+		identity: make(map[snapshotKey]interface{}),
+		parents:  make(map[reflect.Type][]reflect.Type),
+	}
+}
+
+// DependsOn declares that entities of child's model reference those of
+// parent's model, e.g. DependsOn(&Post{}, &User{}) for a post holding its
+// author's id: Commit then writes users before posts and deletes posts
+// before users. Both arguments are struct pointers of the models.
+func (u *UnitOfWork) DependsOn(child, parent interface{}) error {
+	if err := checkEntity(child); err != nil {
+		return err
+	}
+	if err := checkEntity(parent); err != nil {
+		return err
+	}
+	childType, parentType := entityType(child), entityType(parent)
+	u.parents[childType] = append(u.parents[childType], parentType)
+	return nil
+}
+
+// Session returns the session the unit reads and writes with
+func (u *UnitOfWork) Session() *Session {
+	return u.session
+}
+
+// Load returns the entity of type T with the given primary key, reading it
+// only the first time it is asked for within u
+func Load[T any](u *UnitOfWork, pk ...interface{}) (*T, error) {
+	entity := new(T)
+	s := u.session.Model(entity)
+	if known, ok := u.lookup(entity, pk); ok {
+		return known.(*T), nil
+	}
+	if err := s.Get(entity, pk...); err != nil {
+		return nil, err
+	}
+	return u.track(entity).(*T), nil
+}
+
+// FindAll returns the entities of type T matching conds; rows already in
+// the identity map are returned as the tracked pointers, keeping any
+// changes made to them
+func FindAll[T any](u *UnitOfWork, conds ...interface{}) ([]*T, error) {
+	var rows []T
+	if err := u.session.Model(new(T)).Find(&rows, conds...); err != nil {
+		return nil, err
+	}
+	entities := make([]*T, len(rows))
+	for i := range rows {
+		entities[i] = u.track(&rows[i]).(*T)
+	}
+	return entities, nil
+}
+
+// lookup returns the tracked entity with primary key pk, using probe, a new
+// entity of the session model, to build the identity map key
+func (u *UnitOfWork) lookup(probe interface{}, pk []interface{}) (interface{}, bool) {
+	s := u.session
+	primaries := s.Schema.PrimaryFields()
+	if len(primaries) != len(pk) {
+		return nil, false
+	}
+	v := reflect.ValueOf(probe).Elem()
+	for i, field := range primaries {
+		value := reflect.ValueOf(pk[i])
+		target := s.fieldValue(v, field)
+		if !value.IsValid() || !value.Type().ConvertibleTo(target.Type()) {
+			return nil, false
+		}
+		target.Set(value.Convert(target.Type()))
+	}
+	key, ok := s.recordKey(v)
+	if !ok {
+		return nil, false
+	}
+	known, ok := u.identity[key]
+	return known, ok
+}
+
+// track adds a freshly loaded entity to the identity map, or returns the
+// entity already mapped to its primary key
+func (u *UnitOfWork) track(entity interface{}) interface{} {
+	s := u.session.Model(entity)
+	key, ok := s.recordKey(reflect.ValueOf(entity).Elem())
+	if !ok {
+		return entity
+	}
+	if known, ok := u.identity[key]; ok {
+		return known
+	}
+	u.identity[key] = entity
+	u.loaded = append(u.loaded, entity)
+	return entity
+}
+
+// RegisterNew schedules entity, a struct pointer, for insertion
+func (u *UnitOfWork) RegisterNew(entity interface{}) error {
+	if err := checkEntity(entity); err != nil {
+		return err
+	}
+	u.news = append(u.news, entity)
+	return nil
+}
+
+// RegisterDirty schedules entity for saving although it was not loaded
+// through u; loaded entities are saved automatically when changed
+func (u *UnitOfWork) RegisterDirty(entity interface{}) error {
+	if err := checkEntity(entity); err != nil {
+		return err
+	}
+	u.dirty = append(u.dirty, entity)
+	return nil
+}
+
+// RegisterRemoved schedules entity for deletion; a new entity that was
+// never inserted is simply dropped
+func (u *UnitOfWork) RegisterRemoved(entity interface{}) error {
+	if err := checkEntity(entity); err != nil {
+		return err
+	}
+	for i, e := range u.news {
+		if e == entity {
+			u.news = append(u.news[:i], u.news[i+1:]...)
+			return nil
+		}
+	}
+	u.removed = append(u.removed, entity)
+	return nil
+}
+
+// Commit writes all changes in one transaction, in dependency order (see
+// DependsOn): model by model, parents first, new entities are inserted,
+// changed loaded entities are updated by primary key and entities
+// registered dirty are saved; then removed entities are deleted model by
+// model, children first. Within a model entities are written in the order
+// they were registered, removed ones in reverse. A changed loaded entity
+// whose row was deleted meanwhile fails with ErrRecordNotFound rather than
+// being inserted again. On error the transaction is rolled back,
+// generated ids and versions written into the entities are reverted, and
+// the unit keeps its pending changes, so Commit can be retried.
+// When the unit's session is already inside a transaction, the changes are
+// written in it and only become durable when the caller commits it.
+func (u *UnitOfWork) Commit() error {
+	removed := make(map[interface{}]bool, len(u.removed))
+	for _, entity := range u.removed {
+		removed[entity] = true
+	}
+	order, err := u.modelOrder()
+	if err != nil {
+		return err
+	}
+
+	write := func(tx *Session) error {
+		for _, typ := range order {
+			for _, entity := range u.news {
+				if entityType(entity) != typ {
+					continue
+				}
+				if _, err := tx.Model(entity).Insert(entity); err != nil {
+					return err
+				}
+			}
+			for _, entity := range u.loaded {
+				if entityType(entity) != typ || removed[entity] {
+					continue
+				}
+				if err := updateLoaded(tx, entity); err != nil {
+					return err
+				}
+			}
+			for _, entity := range u.dirty {
+				if entityType(entity) != typ || removed[entity] {
+					continue
+				}
+				if _, err := tx.Model(entity).Save(entity); err != nil {
+					return err
+				}
+			}
+		}
+		for i := len(order) - 1; i >= 0; i-- {
+			for j := len(u.removed) - 1; j >= 0; j-- {
+				entity := u.removed[j]
+				if entityType(entity) != order[i] {
+					continue
+				}
+				if _, err := tx.Model(entity).Delete(entity); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// 已经处于事务中时直接写入，由外层事务决定提交或回滚
+	if u.session.tx != nil {
+		err = write(u.session)
+	} else {
+		err = u.session.Transaction(write)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 新插入的记录进入身份映射，删除的记录移出
+	for _, entity := range u.news {
+		u.track(entity)
+	}
+	for _, entity := range u.removed {
+		if key, ok := u.session.Model(entity).recordKey(reflect.ValueOf(entity).Elem()); ok {
+			delete(u.identity, key)
+		}
+	}
+	loaded := u.loaded[:0]
+	for _, entity := range u.loaded {
+		if !removed[entity] {
+			loaded = append(loaded, entity)
+		}
+	}
+	u.loaded = loaded
+	u.news, u.dirty, u.removed = nil, nil, nil
+	return nil
+}
+
+// updateLoaded writes the changes of a loaded entity to its row by primary
+// key; a row deleted since it was loaded is reported, not inserted again
+func updateLoaded(tx *Session, entity interface{}) error {
+	s := tx.Model(entity)
+	affected, skipped, err := s.update(entity, nil)
+	if err != nil {
+		return err
+	}
+	if !skipped && affected == 0 {
+		return fmt.Errorf("%w: %s was deleted since it was loaded", ErrRecordNotFound, s.Schema.Name)
+	}
+	return nil
+}
+
+// modelOrder returns the models of the pending entities with every model
+// after the models it depends on, otherwise in the order they were first
+// registered; a dependency cycle is an error
+func (u *UnitOfWork) modelOrder() ([]reflect.Type, error) {
+	pending := make(map[reflect.Type]bool)
+	var types []reflect.Type
+	for _, entities := range [][]interface{}{u.news, u.loaded, u.dirty, u.removed} {
+		for _, entity := range entities {
+			if typ := entityType(entity); !pending[typ] {
+				pending[typ] = true
+				types = append(types, typ)
+			}
+		}
+	}
+
+	const visiting, done = 1, 2
+	state := make(map[reflect.Type]int)
+	var order []reflect.Type
+	var visit func(typ reflect.Type) error
+	visit = func(typ reflect.Type) error {
+		switch state[typ] {
+		case visiting:
+			return fmt.Errorf("unit of work: dependency cycle through %s", typ)
+		case done:
+			return nil
+		}
+		state[typ] = visiting
+		for _, parent := range u.parents[typ] {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[typ] = done
+		if pending[typ] {
+			order = append(order, typ)
+		}
+		return nil
+	}
+	for _, typ := range types {
+		if err := visit(typ); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// entityType returns the struct type of entity, a struct pointer
+func entityType(entity interface{}) reflect.Type {
+	return reflect.TypeOf(entity).Elem()
+}
+
+// checkEntity requires a non-nil struct pointer, which the unit writes back into
+func checkEntity(entity interface{}) error {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("entity must be a non-nil struct pointer, got %T", entity)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package qsysession_test
+
+import (
+	"errors"
+	"fmt"
+	"qsyorm/qsysession"
+	"testing"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+	uow := qsysession.NewUnitOfWork(s)
+
+	// 同一主键得到同一个指针
+	first, err := qsysession.Load[TestUser](uow, 1)
+	if err != nil {
+		t.Fatal("加载失败:", err)
+	}
+	again, err := qsysession.Load[TestUser](uow, 1)
+	if err != nil || again != first {
+		t.Fatalf("身份映射失效: %p, %p, %v", first, again, err)
+	}
+	first.Age = 50
+
+	users, err := qsysession.FindAll[TestUser](uow, "Age >= ?", 35)
+	if err != nil || len(users) != 2 {
+		t.Fatalf("查询失败: %v, %v", users, err)
+	}
+	all, err := qsysession.FindAll[TestUser](uow)
+	if err != nil || all[0] != first || all[0].Age != 50 {
+		t.Fatalf("查询结果应复用已跟踪的实例: %v, %v", all, err)
+	}
+
+	if err := uow.RegisterNew(&TestUser{Name: "钱七", Age: 20}); err != nil {
+		t.Fatal("登记新记录失败:", err)
+	}
+	if err := uow.RegisterRemoved(users[1]); err != nil {
+		t.Fatal("登记删除失败:", err)
+	}
+	if err := uow.RegisterNew(TestUser{}); err == nil {
+		t.Fatal("期望非指针实体报错")
+	}
+
+	// 提交前数据库没有变化
+	var stored TestUser
+	if err := s.Get(&stored, 1); err != nil || stored.Age != 25 {
+		t.Fatalf("提交前不应写入: %+v, %v", stored, err)
+	}
+
+	if err := uow.Commit(); err != nil {
+		t.Fatal("提交失败:", err)
+	}
+	stored = TestUser{}
+	if err := s.Get(&stored, 1); err != nil || stored.Age != 50 {
+		t.Fatalf("改动未保存: %+v, %v", stored, err)
+	}
+	if n, _ := s.Count(); n != 4 {
+		t.Fatalf("提交后计数错误: %d", n)
+	}
+	if n, _ := s.Count("Name = ?", "钱七"); n != 1 {
+		t.Fatal("新记录未插入")
+	}
+	if n, _ := s.Count("Name = ?", "赵六"); n != 0 {
+		t.Fatal("记录未删除")
+	}
+
+	// 新插入的记录进入身份映射
+	inserted, err := qsysession.Load[TestUser](uow, 5)
+	if err != nil || inserted.Name != "钱七" {
+		t.Fatalf("新记录未进入身份映射: %v, %v", inserted, err)
+	}
+	// 没有改动时再次提交什么也不写
+	if err := uow.Commit(); err != nil {
+		t.Fatal("空提交失败:", err)
+	}
+}
+
+func TestUnitOfWorkRetry(t *testing.T) {
+	s := newTestSession(t, &BatchUser{})
+	if _, err := s.Insert(&BatchUser{Name: "a"}, &BatchUser{Name: "b"}); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+	uow := qsysession.NewUnitOfWork(s)
+
+	loaded, err := qsysession.Load[BatchUser](uow, 1)
+	if err != nil {
+		t.Fatal("加载失败:", err)
+	}
+	loaded.Age = 30
+	created := &BatchUser{Name: "c"}
+	conflict := &BatchUser{ID: 2, Name: "a"}
+	if err := uow.RegisterNew(created); err != nil {
+		t.Fatal("登记新记录失败:", err)
+	}
+	if err := uow.RegisterDirty(conflict); err != nil {
+		t.Fatal("登记修改失败:", err)
+	}
+
+	// 后面的保存失败时整体回滚，待提交的改动保留
+	if err := uow.Commit(); err == nil {
+		t.Fatal("期望唯一约束冲突报错")
+	}
+	if created.ID != 0 {
+		t.Fatalf("回滚后新记录的 ID 应被清零: %d", created.ID)
+	}
+	if n, _ := s.Count(); n != 2 {
+		t.Fatalf("失败的提交不应写入，实际%d条", n)
+	}
+
+	conflict.Name = "b2"
+	if err := uow.Commit(); err != nil {
+		t.Fatal("重试提交失败:", err)
+	}
+	var stored BatchUser
+	if err := s.Get(&stored, 1); err != nil || stored.Age != 30 {
+		t.Fatalf("重试后已加载记录的改动丢失: %+v, %v", stored, err)
+	}
+	if err := s.Get(&stored, created.ID); err != nil || stored.Name != "c" {
+		t.Fatalf("重试后新记录错误: %+v, %v", stored, err)
+	}
+}
+
+func TestUnitOfWorkInTransaction(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	if _, err := s.Insert(&TestUser{Name: "a"}); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	// 在外层事务中提交时不提交外层事务，外层回滚后一起撤销
+	errAbort := errors.New("abort")
+	err := s.Transaction(func(tx *qsysession.Session) error {
+		uow := qsysession.NewUnitOfWork(tx)
+		if err := uow.RegisterNew(&TestUser{Name: "b"}); err != nil {
+			return err
+		}
+		if err := uow.Commit(); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("期望事务返回 errAbort，实际 %v", err)
+	}
+	if n, _ := s.Count(); n != 1 {
+		t.Fatalf("外层事务回滚后记录数应为1，实际%d", n)
+	}
+}
+
+// uowWrites 记录工作单元写入实体的顺序
+var uowWrites []string
+
+type UowAuthor struct {
+	ID   int `qsy:"primarykey;autoincrement"`
+	Name string
+}
+
+func (a *UowAuthor) BeforeInsert() error {
+	uowWrites = append(uowWrites, "insert "+a.Name)
+	return nil
+}
+
+func (a *UowAuthor) BeforeDelete() error {
+	uowWrites = append(uowWrites, "delete "+a.Name)
+	return nil
+}
+
+type UowBook struct {
+	ID       int `qsy:"primarykey;autoincrement"`
+	AuthorID int
+	Title    string
+}
+
+func (b *UowBook) BeforeInsert() error {
+	uowWrites = append(uowWrites, "insert "+b.Title)
+	return nil
+}
+
+func (b *UowBook) BeforeDelete() error {
+	uowWrites = append(uowWrites, "delete "+b.Title)
+	return nil
+}
+
+func TestUnitOfWorkDependencyOrder(t *testing.T) {
+	s := newTestSession(t, &UowAuthor{})
+	if err := s.Model(&UowBook{}).CreateTable(); err != nil {
+		t.Fatal("创建表失败:", err)
+	}
+	uowWrites = nil
+
+	// 子实体先登记，提交时仍然先写入父实体、先删除子实体
+	uow := qsysession.NewUnitOfWork(s)
+	if err := uow.DependsOn(&UowBook{}, &UowAuthor{}); err != nil {
+		t.Fatal("声明依赖失败:", err)
+	}
+	book := &UowBook{Title: "book"}
+	author := &UowAuthor{Name: "author"}
+	if err := uow.RegisterNew(book); err != nil {
+		t.Fatal("登记新记录失败:", err)
+	}
+	if err := uow.RegisterNew(author); err != nil {
+		t.Fatal("登记新记录失败:", err)
+	}
+	if err := uow.Commit(); err != nil {
+		t.Fatal("提交失败:", err)
+	}
+
+	if err := uow.RegisterRemoved(author); err != nil {
+		t.Fatal("登记删除失败:", err)
+	}
+	if err := uow.RegisterRemoved(book); err != nil {
+		t.Fatal("登记删除失败:", err)
+	}
+	if err := uow.Commit(); err != nil {
+		t.Fatal("提交失败:", err)
+	}
+	want := []string{"insert author", "insert book", "delete book", "delete author"}
+	if fmt.Sprint(uowWrites) != fmt.Sprint(want) {
+		t.Fatalf("写入顺序错误: %v", uowWrites)
+	}
+
+	// 循环依赖无法排序
+	cyclic := qsysession.NewUnitOfWork(s)
+	_ = cyclic.DependsOn(&UowBook{}, &UowAuthor{})
+	_ = cyclic.DependsOn(&UowAuthor{}, &UowBook{})
+	if err := cyclic.RegisterNew(&UowAuthor{Name: "x"}); err != nil {
+		t.Fatal("登记新记录失败:", err)
+	}
+	if err := cyclic.Commit(); err == nil {
+		t.Fatal("期望循环依赖报错")
+	}
+}
+
+func TestUnitOfWorkDeletedRow(t *testing.T) {
+	s := newTestSession(t, &TestUser{})
+	seedTestUsers(t, s)
+	uow := qsysession.NewUnitOfWork(s)
+
+	loaded, err := qsysession.Load[TestUser](uow, 1)
+	if err != nil {
+		t.Fatal("加载失败:", err)
+	}
+	loaded.Age = 50
+
+	// 加载之后被其他人删除的行不会被悄悄插回
+	if _, err := s.Raw("DELETE FROM testuser WHERE ID = ?", 1).Exec(); err != nil {
+		t.Fatal("删除失败:", err)
+	}
+	if err := uow.Commit(); !errors.Is(err, qsysession.ErrRecordNotFound) {
+		t.Fatalf("期望 ErrRecordNotFound，实际为 %v", err)
+	}
+	if n, _ := s.Count(); n != 3 {
+		t.Fatalf("已删除的行不应被插回，实际%d条", n)
+	}
+}
@@ -0,0 +1,24 @@
+package qsysession
+
+import "reflect"
+
+// initVersion starts the version of a new record at 1 when it is zero,
+// writing it back, and returns the value to insert
+func initVersion(v reflect.Value) interface{} {
+	if !v.IsZero() {
+		return v.Interface()
+	}
+	if v.CanSet() {
+		setInteger(v, 1)
+	}
+	return int64(1)
+}
+
+// integerValue reads an integer field of any width or signedness
+func integerValue(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint())
+	}
+	return v.Int()
+}
@@ -0,0 +1,91 @@
+package qsysession_test
+
+import (
+	"errors"
+	"qsyorm/qsysession"
+	"testing"
+)
+
+// Document 使用版本号做乐观锁
+type Document struct {
+	ID      int `qsy:"primarykey;autoincrement"`
+	Title   string
+	Version int `qsy:"version"`
+}
+
+func TestOptimisticLock(t *testing.T) {
+	s := newTestSession(t, &Document{})
+	doc := &Document{Title: "draft"}
+	if _, err := s.Insert(doc); err != nil || doc.Version != 1 {
+		t.Fatalf("插入后版本号错误: %+v, %v", doc, err)
+	}
+
+	// 两个管理员读取同一条记录
+	var first, second Document
+	if err := s.Get(&first, doc.ID); err != nil {
+		t.Fatal("读取失败:", err)
+	}
+	if err := s.Get(&second, doc.ID); err != nil {
+		t.Fatal("读取失败:", err)
+	}
+
+	first.Title = "first"
+	if affected, err := s.Save(&first); err != nil || affected != 1 || first.Version != 2 {
+		t.Fatalf("第一次保存错误: %d, %v, %+v", affected, err, first)
+	}
+
+	second.Title = "second"
+	if _, err := s.Where("ID = ?", second.ID).Update(&second); !errors.Is(err, qsysession.ErrStaleObject) {
+		t.Fatalf("期望 ErrStaleObject，实际为 %v", err)
+	}
+	if second.Version != 1 {
+		t.Fatalf("失败的更新不应修改版本号: %+v", second)
+	}
+	if _, err := s.Save(&second); !errors.Is(err, qsysession.ErrStaleObject) {
+		t.Fatalf("期望 Save 返回 ErrStaleObject，实际为 %v", err)
+	}
+
+	var stored Document
+	if err := s.Get(&stored, doc.ID); err != nil || stored.Title != "first" || stored.Version != 2 {
+		t.Fatalf("记录被覆盖: %+v, %v", stored, err)
+	}
+}
+
+func TestOptimisticLockScope(t *testing.T) {
+	s := newTestSession(t, &Document{})
+	docs := []Document{{Title: "a"}, {Title: "b"}}
+	if _, err := s.Insert(docs); err != nil {
+		t.Fatal("插入失败:", err)
+	}
+
+	// 没有条件时也只更新记录自己的行
+	a := docs[0]
+	a.Title = "changed"
+	if affected, err := s.Update(&a); err != nil || affected != 1 || a.Version != 2 {
+		t.Fatalf("带版本号的更新错误: %d, %v, %+v", affected, err, a)
+	}
+	var b Document
+	if err := s.Get(&b, docs[1].ID); err != nil || b.Title != "b" || b.Version != 1 {
+		t.Fatalf("其它记录被修改: %+v, %v", b, err)
+	}
+
+	// 事务回滚后恢复内存中的版本号，重试不会被判为过期
+	errAbort := errors.New("abort")
+	err := s.Transaction(func(tx *qsysession.Session) error {
+		b.Title = "tx"
+		if _, err := tx.Save(&b); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) || b.Version != 1 {
+		t.Fatalf("回滚后版本号错误: %+v, %v", b, err)
+	}
+	if _, err := s.Save(&b); err != nil || b.Version != 2 {
+		t.Fatalf("回滚后重试失败: %+v, %v", b, err)
+	}
+
+	if _, err := s.UpdateBatch(docs); err == nil {
+		t.Fatal("期望 UpdateBatch 拒绝带版本号的模型")
+	}
+}